@@ -35,11 +35,12 @@ package v1alpha1
 
 import (
 	"github.com/operator-framework/operator-lib/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // CopyMethodType defines the methods for creating point-in-time copies of
-// volumes.
-//+kubebuilder:validation:Enum=None;Clone;Snapshot
+// volumes. The permitted values are restricted by the fields that use it.
 type CopyMethodType string
 
 const (
@@ -50,6 +51,11 @@ const (
 	// CopyMethodSnapshot indicates a copy should be created using a volume
 	// snapshot.
 	CopyMethodSnapshot CopyMethodType = "Snapshot"
+	// CopyMethodAuto indicates that the copy method should be chosen based on
+	// the capabilities of the volume's storage, falling back to another
+	// method if the chosen one fails. It is only supported for the source
+	// volumes of the Restic and Rsync TLS movers.
+	CopyMethodAuto CopyMethodType = "Auto"
 )
 
 const (
@@ -61,6 +67,16 @@ const (
 	// ReconciledReasonError indicates an error was encountered while
 	// reconciling the CR
 	ReconciledReasonError status.ConditionReason = "ReconcileError"
+	// ReconciledReasonDeadlineExceeded indicates that the synchronization was
+	// abandoned because it did not complete within its configured deadline
+	ReconciledReasonDeadlineExceeded status.ConditionReason = "SyncDeadlineExceeded"
+	// ReconciledReasonSnapshotFailed indicates that a VolumeSnapshot reported
+	// an error. The snapshot is deleted and retried.
+	ReconciledReasonSnapshotFailed status.ConditionReason = "SnapshotFailed"
+	// ReconciledReasonSnapshotTimeout indicates that a VolumeSnapshot did not
+	// become ready within snapshotTimeoutSeconds. The snapshot is deleted and
+	// retried.
+	ReconciledReasonSnapshotTimeout status.ConditionReason = "SnapshotTimeout"
 )
 
 const (
@@ -69,4 +85,272 @@ const (
 	SynchronizingReasonSched   status.ConditionReason = "WaitingForSchedule"
 	SynchronizingReasonManual  status.ConditionReason = "WaitingForManual"
 	SynchronizingReasonCleanup status.ConditionReason = "CleaningUp"
+	// SynchronizingReasonWaitingForSource indicates that the destination is
+	// ready and waiting for the source to connect and transfer the data
+	SynchronizingReasonWaitingForSource status.ConditionReason = "WaitingForSource"
+	// SynchronizingReasonTimedOut indicates that the previous attempt did not
+	// complete within its timeout and has been restarted
+	SynchronizingReasonTimedOut status.ConditionReason = "TimedOut"
 )
+
+// DeletionPolicyType defines what happens to the images and the external data
+// of a replication relationship when its ReplicationSource or
+// ReplicationDestination is deleted.
+//+kubebuilder:validation:Enum=Retain;Delete
+type DeletionPolicyType string
+
+const (
+	// DeletionPolicyRetain keeps the images and the external data
+	DeletionPolicyRetain DeletionPolicyType = "Retain"
+	// DeletionPolicyDelete removes the images and the external data before
+	// the object is deleted
+	DeletionPolicyDelete DeletionPolicyType = "Delete"
+)
+
+// CopiedSSHKeysLabel marks an SSH keys Secret that was copied from the
+// destination into the namespace of the ReplicationSource. Marked Secrets are
+// removed along with a ReplicationSource that uses them and has a
+// deletionPolicy of Delete.
+const CopiedSSHKeysLabel = "volsync.backube/copied-ssh-keys"
+
+// ResticJobOptions controls how the restic mover Job is retried and how long it
+// is permitted to run.
+type ResticJobOptions struct {
+	// backoffLimit is the number of times a failed restic attempt will be
+	// retried before the synchronization is restarted. Defaults to 8.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// attemptTimeoutSeconds is the maximum amount of time that a single restic
+	// attempt may run before it is terminated and counted as a failure.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	AttemptTimeoutSeconds *int64 `json:"attemptTimeoutSeconds,omitempty"`
+	// syncTimeoutSeconds is the maximum amount of time, including all retries,
+	// that a synchronization may take. When it is exceeded, the attempt is
+	// abandoned and a new one is started.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	SyncTimeoutSeconds *int64 `json:"syncTimeoutSeconds,omitempty"`
+}
+
+// ResticInitializePolicyType defines whether the restic mover may create the
+// repository if it does not already exist.
+//+kubebuilder:validation:Enum=Auto;Never;RequireExisting
+type ResticInitializePolicyType string
+
+const (
+	// ResticInitializeAuto creates the repository if it does not exist
+	ResticInitializeAuto ResticInitializePolicyType = "Auto"
+	// ResticInitializeNever skips checking for and initializing the repository
+	ResticInitializeNever ResticInitializePolicyType = "Never"
+	// ResticInitializeRequireExisting fails the synchronization if the
+	// repository does not exist
+	ResticInitializeRequireExisting ResticInitializePolicyType = "RequireExisting"
+)
+
+// ResticRepositoryStateType describes the state of the restic repository as
+// observed by the mover.
+type ResticRepositoryStateType string
+
+const (
+	// ResticRepositoryFound indicates the repository already existed
+	ResticRepositoryFound ResticRepositoryStateType = "Found"
+	// ResticRepositoryCreated indicates the repository was initialized by the
+	// mover
+	ResticRepositoryCreated ResticRepositoryStateType = "Created"
+	// ResticRepositoryMissing indicates the repository does not exist and the
+	// initialize policy did not permit creating it
+	ResticRepositoryMissing ResticRepositoryStateType = "Missing"
+)
+
+// SSHKeyType defines the type of SSH keys that are generated for rsync.
+//+kubebuilder:validation:Enum=ed25519;rsa
+type SSHKeyType string
+
+const (
+	// SSHKeyTypeED25519 generates ed25519 keys
+	SSHKeyTypeED25519 SSHKeyType = "ed25519"
+	// SSHKeyTypeRSA generates 4096-bit RSA keys
+	SSHKeyTypeRSA SSHKeyType = "rsa"
+)
+
+// SSHKeyRotationSpec controls how generated SSH keys are rotated.
+type SSHKeyRotationSpec struct {
+	// maxAge is the age after which the generated keys are replaced. If not
+	// set, keys are only rotated on request.
+	//+optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+	// gracePeriod is how long the previous keys continue to be accepted after
+	// a rotation, providing time to distribute the new keys to the other
+	// cluster. Defaults to 24h.
+	//+optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// RsyncServiceOptions customize the Service that is created for incoming
+// replication connections.
+type RsyncServiceOptions struct {
+	// serviceAnnotations are additional annotations to apply to the Service.
+	// Unless overridden here, LoadBalancer Services are annotated with
+	// "service.beta.kubernetes.io/aws-load-balancer-type: nlb".
+	//+optional
+	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
+	// serviceLabels are additional labels to apply to the Service.
+	//+optional
+	ServiceLabels map[string]string `json:"serviceLabels,omitempty"`
+	// loadBalancerSourceRanges restricts the client addresses that may
+	// connect when the serviceType is LoadBalancer.
+	//+optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// ipFamilyPolicy determines whether the Service is single-stack or
+	// dual-stack. Defaults to the cluster's default.
+	//+optional
+	IPFamilyPolicy *corev1.IPFamilyPolicyType `json:"ipFamilyPolicy,omitempty"`
+}
+
+// RsyncProxySpec describes an intermediary through which the mover's outgoing
+// SSH connection is made. Only one of jumpHost or httpProxy may be specified.
+type RsyncProxySpec struct {
+	// jumpHost is the SSH bastion through which to connect, in the form
+	// [user@]host[:port]. The user defaults to "root" and the port to 22.
+	//+optional
+	JumpHost *string `json:"jumpHost,omitempty"`
+	// jumpHostKeys is the name of a Secret that contains the private key used
+	// to authenticate with the jump host (in the field "jump") and the jump
+	// host's public host key (in the field "jumphost.pub"), which is used to
+	// verify the jump host. It is required when jumpHost is set.
+	//+optional
+	JumpHostKeys *string `json:"jumpHostKeys,omitempty"`
+	// httpProxy is the URL of an HTTP proxy that permits the CONNECT method
+	// (e.g., http://proxy.example.com:3128). It must not include credentials.
+	//+optional
+	HTTPProxy *string `json:"httpProxy,omitempty"`
+	// httpProxyCredentials is the name of a Secret that contains the username
+	// (in the field "username") and password (in the field "password") used
+	// to authenticate with the HTTP proxy.
+	//+optional
+	HTTPProxyCredentials *string `json:"httpProxyCredentials,omitempty"`
+}
+
+// RcloneModeType determines how Rclone updates the remote from the source
+// volume.
+//+kubebuilder:validation:Enum=Sync;Copy;Backup;Bisync
+type RcloneModeType string
+
+const (
+	// RcloneModeSync makes the remote match the volume, deleting files that
+	// are no longer present
+	RcloneModeSync RcloneModeType = "Sync"
+	// RcloneModeCopy copies new and changed files, but never deletes files
+	// from the remote
+	RcloneModeCopy RcloneModeType = "Copy"
+	// RcloneModeBackup makes the remote match the volume, but moves changed
+	// and deleted files into a timestamped directory instead of discarding
+	// them
+	RcloneModeBackup RcloneModeType = "Backup"
+	// RcloneModeBisync propagates changes in both directions between the
+	// volume and the remote
+	RcloneModeBisync RcloneModeType = "Bisync"
+)
+
+// RcloneConflictResolutionType determines how a file that has been changed
+// both in the volume and on the remote is handled by a bidirectional sync.
+//+kubebuilder:validation:Enum=NewerWins;KeepBoth;SourceWins
+type RcloneConflictResolutionType string
+
+const (
+	// RcloneConflictNewerWins keeps the most recently modified version
+	RcloneConflictNewerWins RcloneConflictResolutionType = "NewerWins"
+	// RcloneConflictKeepBoth keeps both versions, renaming each of them with
+	// a conflict suffix
+	RcloneConflictKeepBoth RcloneConflictResolutionType = "KeepBoth"
+	// RcloneConflictSourceWins keeps the version in the volume
+	RcloneConflictSourceWins RcloneConflictResolutionType = "SourceWins"
+)
+
+// RcloneBackendSpec describes the remote storage used by Rclone. Exactly one
+// backend must be specified. Credentials are read from Secrets in the same
+// namespace.
+type RcloneBackendSpec struct {
+	// s3 configures Amazon S3 or an S3-compatible object store.
+	//+optional
+	S3 *RcloneS3Backend `json:"s3,omitempty"`
+	// azureBlob configures Azure Blob storage.
+	//+optional
+	AzureBlob *RcloneAzureBlobBackend `json:"azureBlob,omitempty"`
+	// gcs configures Google Cloud Storage.
+	//+optional
+	GCS *RcloneGCSBackend `json:"gcs,omitempty"`
+	// sftp configures an SFTP server.
+	//+optional
+	SFTP *RcloneSFTPBackend `json:"sftp,omitempty"`
+	// local uses a path within the mover container. It is primarily useful
+	// for testing.
+	//+optional
+	Local *RcloneLocalBackend `json:"local,omitempty"`
+}
+
+// RcloneS3Backend configures an S3-compatible object store.
+type RcloneS3Backend struct {
+	// provider is the S3 provider (e.g., AWS, Ceph, Minio). Defaults to
+	// "Other".
+	//+optional
+	Provider *string `json:"provider,omitempty"`
+	// endpoint is the URL of the object store. It is not required for AWS.
+	//+optional
+	Endpoint *string `json:"endpoint,omitempty"`
+	// region is the region in which the bucket resides.
+	//+optional
+	Region *string `json:"region,omitempty"`
+	// accessKeyID references the Secret field that holds the access key ID.
+	AccessKeyID *corev1.SecretKeySelector `json:"accessKeyID,omitempty"`
+	// secretAccessKey references the Secret field that holds the secret
+	// access key.
+	SecretAccessKey *corev1.SecretKeySelector `json:"secretAccessKey,omitempty"`
+}
+
+// RcloneAzureBlobBackend configures Azure Blob storage.
+type RcloneAzureBlobBackend struct {
+	// account is the name of the storage account.
+	Account string `json:"account,omitempty"`
+	// key references the Secret field that holds the storage account key.
+	Key *corev1.SecretKeySelector `json:"key,omitempty"`
+	// endpoint overrides the endpoint of the service.
+	//+optional
+	Endpoint *string `json:"endpoint,omitempty"`
+}
+
+// RcloneGCSBackend configures Google Cloud Storage.
+type RcloneGCSBackend struct {
+	// serviceAccountCredentials references the Secret field that holds the
+	// JSON credentials of a service account.
+	ServiceAccountCredentials *corev1.SecretKeySelector `json:"serviceAccountCredentials,omitempty"`
+	// projectNumber is the project number, which is only needed to create
+	// buckets.
+	//+optional
+	ProjectNumber *string `json:"projectNumber,omitempty"`
+	// bucketPolicyOnly should be set when the bucket uses uniform
+	// bucket-level access.
+	//+optional
+	BucketPolicyOnly bool `json:"bucketPolicyOnly,omitempty"`
+}
+
+// RcloneSFTPBackend configures an SFTP server.
+type RcloneSFTPBackend struct {
+	// host is the address of the SFTP server.
+	Host string `json:"host,omitempty"`
+	// port is the port of the SFTP server. Defaults to 22.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	//+optional
+	Port *int32 `json:"port,omitempty"`
+	// user is the username used to log in.
+	User string `json:"user,omitempty"`
+	// privateKey references the Secret field that holds the PEM-encoded
+	// private key used to log in.
+	PrivateKey *corev1.SecretKeySelector `json:"privateKey,omitempty"`
+}
+
+// RcloneLocalBackend uses the filesystem of the mover container.
+type RcloneLocalBackend struct{}
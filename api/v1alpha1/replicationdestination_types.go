@@ -63,6 +63,7 @@ type ReplicationDestinationTriggerSpec struct {
 type ReplicationDestinationVolumeOptions struct {
 	// copyMethod describes how a point-in-time (PiT) image of the destination
 	// volume should be created.
+	//+kubebuilder:validation:Enum=None;Clone;Snapshot
 	CopyMethod CopyMethodType `json:"copyMethod,omitempty"`
 	// capacity is the size of the destination volume to create.
 	//+optional
@@ -75,10 +76,22 @@ type ReplicationDestinationVolumeOptions struct {
 	//+kubebuilder:validation:MinItems=1
 	//+optional
 	AccessModes []v1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// volumeMode is the volume mode of the destination volume to create. It
+	// should match the volume mode of the source volume. Allowed values are
+	// Filesystem and Block. Defaults to Filesystem.
+	//+kubebuilder:validation:Enum=Filesystem;Block
+	//+optional
+	VolumeMode *v1.PersistentVolumeMode `json:"volumeMode,omitempty"`
 	// volumeSnapshotClassName can be used to specify the VSC to be used if
 	// copyMethod is Snapshot. If not set, the default VSC is used.
 	//+optional
 	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+	// snapshotTimeoutSeconds is the amount of time to wait for a VolumeSnapshot
+	// to become ready to use. A snapshot that takes longer, or that reports an
+	// error, is deleted and retried. If not set, there is no timeout.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	SnapshotTimeoutSeconds *int32 `json:"snapshotTimeoutSeconds,omitempty"`
 	// destinationPVC is a PVC to use as the transfer destination instead of
 	// automatically provisioning one. Either this field or both capacity and
 	// accessModes must be specified.
@@ -92,10 +105,23 @@ type ReplicationDestinationRsyncSpec struct {
 	// authentication. If not provided, the keys will be generated.
 	//+optional
 	SSHKeys *string `json:"sshKeys,omitempty"`
+	// sshKeyType is the type of SSH keys that are generated when sshKeys is
+	// not provided. Changing it takes effect at the next key rotation.
+	// Defaults to "ed25519".
+	//+optional
+	SSHKeyType *SSHKeyType `json:"sshKeyType,omitempty"`
+	// sshKeyRotation controls the rotation of generated SSH keys. Keys may
+	// also be rotated by changing the value of the
+	// volsync.backube/rotate-ssh-keys annotation on this object.
+	//+optional
+	SSHKeyRotation *SSHKeyRotationSpec `json:"sshKeyRotation,omitempty"`
 	// serviceType determines the Service type that will be created for incoming
-	// SSH connections.
+	// SSH connections. Allowed values are ClusterIP, LoadBalancer, and
+	// NodePort.
 	//+optional
 	ServiceType *v1.ServiceType `json:"serviceType,omitempty"`
+	// Options for the Service that is created for incoming SSH connections
+	RsyncServiceOptions `json:",inline"`
 	// address is the remote address to connect to for replication.
 	//+optional
 	Address *string `json:"address,omitempty"`
@@ -110,6 +136,17 @@ type ReplicationDestinationRsyncSpec struct {
 	// sshUser is the username for outgoing SSH connections. Defaults to "root".
 	//+optional
 	SSHUser *string `json:"sshUser,omitempty"`
+	// connectionTimeoutSeconds is how long the destination waits for the
+	// source to connect before the listener is restarted. By default, it waits
+	// indefinitely.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	ConnectionTimeoutSeconds *int64 `json:"connectionTimeoutSeconds,omitempty"`
+	// transferTimeoutSeconds is the maximum amount of time a transfer may take
+	// once the source has connected. By default, there is no limit.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	TransferTimeoutSeconds *int64 `json:"transferTimeoutSeconds,omitempty"`
 }
 
 // ReplicationDestinationRcloneSpec defines the field for rclone in replicationSource.
@@ -121,6 +158,11 @@ type ReplicationDestinationRcloneSpec struct {
 	RcloneDestPath *string `json:"rcloneDestPath,omitempty"`
 	// RcloneConfig is the rclone secret name
 	RcloneConfig *string `json:"rcloneConfig,omitempty"`
+	// rcloneBackend describes the remote storage. When it is specified, the
+	// rclone configuration is generated from it, and rcloneConfig and
+	// rcloneConfigSection must not be set.
+	//+optional
+	RcloneBackend *RcloneBackendSpec `json:"rcloneBackend,omitempty"`
 }
 
 // ReplicationDestinationExternalSpec defines the configuration when using an
@@ -135,6 +177,60 @@ type ReplicationDestinationExternalSpec struct {
 	Parameters map[string]string `json:"parameters,omitempty"`
 }
 
+// ReplicationDestinationRsyncTLSSpec defines the configuration when using
+// rsync-over-TLS replication.
+type ReplicationDestinationRsyncTLSSpec struct {
+	ReplicationDestinationVolumeOptions `json:",inline"`
+	// keySecret is the name of a Secret that contains the TLS pre-shared key
+	// (in the field "psk.txt") used to authenticate the source. If not
+	// provided, a key will be generated and the name of its Secret will be
+	// placed in .status.rsyncTLS.keySecret.
+	//+optional
+	KeySecret *string `json:"keySecret,omitempty"`
+	// serviceType determines the Service type that will be created for incoming
+	// TLS connections.
+	//+optional
+	ServiceType *v1.ServiceType `json:"serviceType,omitempty"`
+}
+
+// LatestPVCUpdateStrategyType defines how the latestPVC is replaced when a
+// newer image is available.
+//+kubebuilder:validation:Enum=WhenUnused;Recreate
+type LatestPVCUpdateStrategyType string
+
+const (
+	// LatestPVCUpdateWhenUnused waits until no Pod is using the PVC before
+	// replacing it
+	LatestPVCUpdateWhenUnused LatestPVCUpdateStrategyType = "WhenUnused"
+	// LatestPVCUpdateRecreate deletes and recreates the PVC, even if it is in
+	// use. The deletion completes once the Pods using it have been removed.
+	LatestPVCUpdateRecreate LatestPVCUpdateStrategyType = "Recreate"
+)
+
+// ReplicationDestinationLatestPVCSpec defines a PVC that is kept provisioned
+// from the most recent image.
+type ReplicationDestinationLatestPVCSpec struct {
+	// name of the PVC to provision from the latest image.
+	//+kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// accessModes of the PVC. If not set, the access modes of the replicated
+	// volume are used.
+	//+optional
+	AccessModes []v1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// capacity of the PVC. If not set, the size of the image is used.
+	//+optional
+	Capacity *resource.Quantity `json:"capacity,omitempty"`
+	// storageClassName of the PVC. If not set, the StorageClass of the
+	// replicated volume is used.
+	//+optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// updateStrategy determines how the PVC is replaced when a newer image is
+	// available. WhenUnused (the default) waits until no Pod is using the PVC,
+	// while Recreate replaces it right away.
+	//+optional
+	UpdateStrategy LatestPVCUpdateStrategyType `json:"updateStrategy,omitempty"`
+}
+
 // ReplicationDestinationSpec defines the desired state of
 // ReplicationDestination
 type ReplicationDestinationSpec struct {
@@ -148,6 +244,10 @@ type ReplicationDestinationSpec struct {
 	// rclone defines the configuration when using Rclone-based replication.
 	//+optional
 	Rclone *ReplicationDestinationRcloneSpec `json:"rclone,omitempty"`
+	// rsyncTLS defines the configuration when using rsync-over-TLS
+	// replication.
+	//+optional
+	RsyncTLS *ReplicationDestinationRsyncTLSSpec `json:"rsyncTLS,omitempty"`
 	// restic defines the configuration when using Restic-based replication.
 	//+optional
 	Restic *ReplicationDestinationResticSpec `json:"restic,omitempty"`
@@ -155,6 +255,22 @@ type ReplicationDestinationSpec struct {
 	// provider.
 	//+optional
 	External *ReplicationDestinationExternalSpec `json:"external,omitempty"`
+	// latestPVC, if specified, keeps a PVC with the given name provisioned
+	// from the latest image, so that it can be used directly by an
+	// application.
+	//+optional
+	LatestPVC *ReplicationDestinationLatestPVCSpec `json:"latestPVC,omitempty"`
+	// deletionPolicy determines whether the latest image is kept when the
+	// ReplicationDestination is deleted. If not specified, the images are
+	// removed along with the ReplicationDestination.
+	//+optional
+	DeletionPolicy DeletionPolicyType `json:"deletionPolicy,omitempty"`
+	// moverServiceAccount is the name of an existing ServiceAccount (in the
+	// same namespace) that the data mover runs as, e.g., to obtain cloud
+	// credentials via workload identity or to supply imagePullSecrets. If not
+	// specified, a ServiceAccount is created for the mover.
+	//+optional
+	MoverServiceAccount *string `json:"moverServiceAccount,omitempty"`
 	// paused can be used to temporarily stop replication. Defaults to "false".
 	//+optional
 	Paused bool `json:"paused,omitempty"`
@@ -171,17 +287,89 @@ type ReplicationDestinationRsyncStatus struct {
 	// connections.
 	//+optional
 	Address *string `json:"address,omitempty"`
+	// addresses lists all of the addresses (e.g., both IPv4 and IPv6 for a
+	// dual-stack Service) at which incoming SSH replication connections are
+	// accepted. The first entry matches address.
+	//+optional
+	Addresses []string `json:"addresses,omitempty"`
 	// port is the SSH port to connect to for incoming SSH replication
 	// connections.
 	//+optional
 	Port *int32 `json:"port,omitempty"`
 }
 
+// ResticTargetCleanupType defines how pre-existing data in the destination
+// volume is handled during a restore.
+//+kubebuilder:validation:Enum=None;Clean;DeleteExtraneous
+type ResticTargetCleanupType string
+
+const (
+	// ResticTargetCleanupNone restores on top of the existing data
+	ResticTargetCleanupNone ResticTargetCleanupType = "None"
+	// ResticTargetCleanupClean removes all existing data from the target
+	// directory prior to restoring
+	ResticTargetCleanupClean ResticTargetCleanupType = "Clean"
+	// ResticTargetCleanupDeleteExtraneous removes files from the target
+	// directory that are not present in the restored snapshot
+	ResticTargetCleanupDeleteExtraneous ResticTargetCleanupType = "DeleteExtraneous"
+)
+
+// ResticDestinationModeType defines what the restic destination does with the
+// data from the repository.
+//+kubebuilder:validation:Enum=Restore;Verify
+type ResticDestinationModeType string
+
+const (
+	// ResticDestinationModeRestore restores the data into the destination
+	// volume
+	ResticDestinationModeRestore ResticDestinationModeType = "Restore"
+	// ResticDestinationModeVerify performs a test restore into a temporary
+	// volume and checks the result against the repository
+	ResticDestinationModeVerify ResticDestinationModeType = "Verify"
+)
+
 // ReplicationDestinationResticSpec defines the field for restic in replicationDestination.
 type ReplicationDestinationResticSpec struct {
 	ReplicationDestinationVolumeOptions `json:",inline"`
 	// Repository is the secret name containing repository info
 	Repository string `json:"repository,omitempty"`
+	// initialize determines whether the repository is created if it does not
+	// exist ("Auto"), must already exist ("RequireExisting"), or is not checked
+	// at all ("Never"). Defaults to "RequireExisting".
+	//+optional
+	Initialize *ResticInitializePolicyType `json:"initialize,omitempty"`
+	// mode selects whether the data is restored into the destination volume
+	// ("Restore") or whether a test restore is performed into a temporary
+	// volume to verify that the backup is usable ("Verify"). Defaults to
+	// "Restore".
+	//+optional
+	Mode *ResticDestinationModeType `json:"mode,omitempty"`
+	// snapshot is the ID of the restic snapshot to restore. If not set, the
+	// latest snapshot is restored.
+	//+optional
+	Snapshot *string `json:"snapshot,omitempty"`
+	// targetCleanup determines how data already in the destination volume is
+	// handled. "None" restores on top of the existing data, "Clean" empties the
+	// target directory before restoring, and "DeleteExtraneous" removes any
+	// files that are not in the snapshot after restoring. Defaults to "None".
+	//+optional
+	TargetCleanup *ResticTargetCleanupType `json:"targetCleanup,omitempty"`
+	// include is a list of patterns. If provided, only the matching files are
+	// restored.
+	//+optional
+	Include []string `json:"include,omitempty"`
+	// exclude is a list of patterns for files that should not be restored.
+	//+optional
+	Exclude []string `json:"exclude,omitempty"`
+	// targetSubdirectory is a path, relative to the root of the destination
+	// volume, that the data should be restored into. Defaults to the root of
+	// the volume.
+	//+optional
+	TargetSubdirectory *string `json:"targetSubdirectory,omitempty"`
+	// verify causes the restored files to be checked against the snapshot
+	// after they have been written.
+	//+optional
+	Verify bool `json:"verify,omitempty"`
 	// cacheCapacity can be used to set the size of the restic metadata cache volume
 	//+optional
 	CacheCapacity *resource.Quantity `json:"cacheCapacity,omitempty"`
@@ -192,6 +380,63 @@ type ReplicationDestinationResticSpec struct {
 	// accessModes can be used to set the accessModes of restic metadata cache volume
 	//+optional
 	CacheAccessModes []v1.PersistentVolumeAccessMode `json:"cacheAccessModes,omitempty"`
+	ResticJobOptions `json:",inline"`
+}
+
+// ResticVerificationStatus records the outcome of a test restore.
+type ResticVerificationStatus struct {
+	// time is when the verification completed.
+	//+optional
+	Time *metav1.Time `json:"time,omitempty"`
+	// passed is true if the snapshot was successfully restored and matched
+	// the contents of the repository.
+	Passed bool `json:"passed"`
+	// duration is the amount of time the verification took.
+	//+optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// ReplicationDestinationResticStatus defines the status of a restic
+// destination.
+type ReplicationDestinationResticStatus struct {
+	// lastVerification is the result of the most recent test restore.
+	//+optional
+	LastVerification *ResticVerificationStatus `json:"lastVerification,omitempty"`
+	// repositoryState records whether the repository was found or created
+	// during the most recent synchronization.
+	//+optional
+	RepositoryState ResticRepositoryStateType `json:"repositoryState,omitempty"`
+}
+
+// ReplicationDestinationRcloneStatus defines the status of an Rclone
+// destination.
+type ReplicationDestinationRcloneStatus struct {
+	// permissionsRestoreError describes why the ownership, permissions, and
+	// ACLs of the files could not be restored during the most recent
+	// synchronization. It is empty if they were restored successfully.
+	//+optional
+	PermissionsRestoreError string `json:"permissionsRestoreError,omitempty"`
+}
+
+// ReplicationDestinationRsyncTLSStatus defines the status of an
+// rsync-over-TLS destination.
+type ReplicationDestinationRsyncTLSStatus struct {
+	// address is the address to connect to for incoming replication
+	// connections.
+	//+optional
+	Address *string `json:"address,omitempty"`
+	// addresses lists all of the addresses (e.g., both IPv4 and IPv6 for a
+	// dual-stack Service) at which incoming replication connections are
+	// accepted. The first entry matches address.
+	//+optional
+	Addresses []string `json:"addresses,omitempty"`
+	// port is the port to connect to for incoming replication connections.
+	//+optional
+	Port *int32 `json:"port,omitempty"`
+	// keySecret is the name of the Secret that contains the pre-shared key
+	// that the source must use.
+	//+optional
+	KeySecret *string `json:"keySecret,omitempty"`
 }
 
 // ReplicationDestinationStatus defines the observed state of ReplicationDestination
@@ -214,8 +459,21 @@ type ReplicationDestinationStatus struct {
 	// image.
 	//+optional
 	LatestImage *v1.TypedLocalObjectReference `json:"latestImage,omitempty"`
+	// latestPVC contains the status of the PVC provisioned from the latest
+	// image.
+	//+optional
+	LatestPVC *ReplicationDestinationLatestPVCStatus `json:"latestPVC,omitempty"`
 	// rsync contains status information for Rsync-based replication.
 	Rsync *ReplicationDestinationRsyncStatus `json:"rsync,omitempty"`
+	// restic contains status information for Restic-based replication.
+	//+optional
+	Restic *ReplicationDestinationResticStatus `json:"restic,omitempty"`
+	// rsyncTLS contains status information for rsync-over-TLS replication.
+	//+optional
+	RsyncTLS *ReplicationDestinationRsyncTLSStatus `json:"rsyncTLS,omitempty"`
+	// rclone contains status information for Rclone-based replication.
+	//+optional
+	Rclone *ReplicationDestinationRcloneStatus `json:"rclone,omitempty"`
 	// external contains provider-specific status information. For more details,
 	// please see the documentation of the specific replication provider being
 	// used.
@@ -226,6 +484,18 @@ type ReplicationDestinationStatus struct {
 	Conditions status.Conditions `json:"conditions,omitempty"`
 }
 
+// ReplicationDestinationLatestPVCStatus defines the status of the PVC that is
+// provisioned from the most recent image.
+type ReplicationDestinationLatestPVCStatus struct {
+	// sourceImage is the image that the latestPVC was provisioned from.
+	//+optional
+	SourceImage *v1.TypedLocalObjectReference `json:"sourceImage,omitempty"`
+	// updatePending is true if a newer image is available, but the latestPVC
+	// hasn't been replaced because it is in use.
+	//+optional
+	UpdatePending bool `json:"updatePending,omitempty"`
+}
+
 // ReplicationDestination defines the destination for a replicated volume
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:scope=Namespaced
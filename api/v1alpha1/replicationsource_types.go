@@ -74,7 +74,9 @@ type ReplicationSourceExternalSpec struct {
 
 type ReplicationSourceVolumeOptions struct {
 	// copyMethod describes how a point-in-time (PiT) image of the source volume
-	// should be created.
+	// should be created. Auto is only supported by the Restic and Rsync TLS
+	// movers.
+	//+kubebuilder:validation:Enum=None;Clone;Snapshot;Auto
 	CopyMethod CopyMethodType `json:"copyMethod,omitempty"`
 	// capacity can be used to override the capacity of the PiT image.
 	//+optional
@@ -91,6 +93,12 @@ type ReplicationSourceVolumeOptions struct {
 	// copyMethod is Snapshot. If not set, the default VSC is used.
 	//+optional
 	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+	// snapshotTimeoutSeconds is the amount of time to wait for a VolumeSnapshot
+	// to become ready to use. A snapshot that takes longer, or that reports an
+	// error, is deleted and retried. If not set, there is no timeout.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	SnapshotTimeoutSeconds *int32 `json:"snapshotTimeoutSeconds,omitempty"`
 }
 
 type ReplicationSourceRsyncSpec struct {
@@ -99,10 +107,23 @@ type ReplicationSourceRsyncSpec struct {
 	// authentication. If not provided, the keys will be generated.
 	//+optional
 	SSHKeys *string `json:"sshKeys,omitempty"`
+	// sshKeyType is the type of SSH keys that are generated when sshKeys is
+	// not provided. Changing it takes effect at the next key rotation.
+	// Defaults to "ed25519".
+	//+optional
+	SSHKeyType *SSHKeyType `json:"sshKeyType,omitempty"`
+	// sshKeyRotation controls the rotation of generated SSH keys. Keys may
+	// also be rotated by changing the value of the
+	// volsync.backube/rotate-ssh-keys annotation on this object.
+	//+optional
+	SSHKeyRotation *SSHKeyRotationSpec `json:"sshKeyRotation,omitempty"`
 	// serviceType determines the Service type that will be created for incoming
-	// SSH connections.
+	// SSH connections. Allowed values are ClusterIP, LoadBalancer, and
+	// NodePort.
 	//+optional
 	ServiceType *v1.ServiceType `json:"serviceType,omitempty"`
+	// Options for the Service that is created for incoming SSH connections
+	RsyncServiceOptions `json:",inline"`
 	// address is the remote address to connect to for replication.
 	//+optional
 	Address *string `json:"address,omitempty"`
@@ -117,6 +138,26 @@ type ReplicationSourceRsyncSpec struct {
 	// sshUser is the username for outgoing SSH connections. Defaults to "root".
 	//+optional
 	SSHUser *string `json:"sshUser,omitempty"`
+	// proxy configures outgoing SSH connections to pass through a jump host
+	// or HTTP proxy.
+	//+optional
+	Proxy *RsyncProxySpec `json:"proxy,omitempty"`
+	// syncTimeoutSeconds is the maximum amount of time, including all
+	// retries, that a synchronization may take. When it is exceeded, the
+	// attempt is abandoned and a new one is started. By default, there is no
+	// limit.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	SyncTimeoutSeconds *int64 `json:"syncTimeoutSeconds,omitempty"`
+	// parallelism is the number of concurrent rsync streams used to transfer
+	// the volume. The top-level directories of the volume are divided among
+	// the streams, which share a single ssh connection. This speeds up the
+	// transfer of volumes with many small files. A single stream is used if
+	// the volume contains hard links. The default is 1.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=8
+	//+optional
+	Parallelism *int32 `json:"parallelism,omitempty"`
 }
 
 // ReplicationSourceRcloneSpec defines the field for rclone in replicationSource.
@@ -128,6 +169,36 @@ type ReplicationSourceRcloneSpec struct {
 	RcloneDestPath *string `json:"rcloneDestPath,omitempty"`
 	// RcloneConfig is the rclone secret name
 	RcloneConfig *string `json:"rcloneConfig,omitempty"`
+	// rcloneBackend describes the remote storage. When it is specified, the
+	// rclone configuration is generated from it, and rcloneConfig and
+	// rcloneConfigSection must not be set.
+	//+optional
+	RcloneBackend *RcloneBackendSpec `json:"rcloneBackend,omitempty"`
+	// rcloneMode determines how the remote is updated. Sync (the default)
+	// makes the remote match the volume. Copy never deletes files from the
+	// remote. Backup is like Sync, but keeps the previous versions of changed
+	// and deleted files in a timestamped directory on the remote. Bisync
+	// propagates changes in both directions and requires a copyMethod of None.
+	//+optional
+	RcloneMode *RcloneModeType `json:"rcloneMode,omitempty"`
+	// rcloneBackupRetention is the number of timestamped directories of
+	// previous versions to keep when rcloneMode is Backup. Defaults to 7.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	RcloneBackupRetention *int32 `json:"rcloneBackupRetention,omitempty"`
+	// bisyncConflictResolution determines how files that were changed both in
+	// the volume and on the remote are handled when rcloneMode is Bisync.
+	// Defaults to NewerWins.
+	//+optional
+	BisyncConflictResolution *RcloneConflictResolutionType `json:"bisyncConflictResolution,omitempty"`
+	// bisyncStateCapacity is the size of the volume that holds the state of
+	// the bidirectional sync between runs. Defaults to 1Gi.
+	//+optional
+	BisyncStateCapacity *resource.Quantity `json:"bisyncStateCapacity,omitempty"`
+	// bisyncStateStorageClassName is the StorageClass of the volume that holds
+	// the state of the bidirectional sync.
+	//+optional
+	BisyncStateStorageClassName *string `json:"bisyncStateStorageClassName,omitempty"`
 }
 
 // ResticRetainPolicy defines the feilds for Restic backup
@@ -162,6 +233,11 @@ type ReplicationSourceResticSpec struct {
 	// ResticRetainPolicy define the retain policy
 	//+optional
 	Retain *ResticRetainPolicy `json:"retain,omitempty"`
+	// initialize determines whether the repository is created if it does not
+	// exist ("Auto"), must already exist ("RequireExisting"), or is not checked
+	// at all ("Never"). Defaults to "Auto".
+	//+optional
+	Initialize *ResticInitializePolicyType `json:"initialize,omitempty"`
 	// cacheCapacity can be used to set the size of the restic metadata cache volume
 	//+optional
 	CacheCapacity *resource.Quantity `json:"cacheCapacity,omitempty"`
@@ -172,6 +248,27 @@ type ReplicationSourceResticSpec struct {
 	// accessModes can be used to set the accessModes of restic metadata cache volume
 	//+optional
 	CacheAccessModes []v1.PersistentVolumeAccessMode `json:"cacheAccessModes,omitempty"`
+	ResticJobOptions `json:",inline"`
+}
+
+// ResticSnapshot describes a single snapshot that is present in the restic
+// repository.
+type ResticSnapshot struct {
+	// id is the (short) restic ID of the snapshot.
+	ID string `json:"id"`
+	// time is when the snapshot was taken.
+	//+optional
+	Time *metav1.Time `json:"time,omitempty"`
+	// size is the amount of data that would be written when restoring the
+	// snapshot.
+	//+optional
+	Size *resource.Quantity `json:"size,omitempty"`
+	// paths are the directories that are contained in the snapshot.
+	//+optional
+	Paths []string `json:"paths,omitempty"`
+	// tags are the restic tags attached to the snapshot.
+	//+optional
+	Tags []string `json:"tags,omitempty"`
 }
 
 //ReplicationSourceResticStatus defines the field for ReplicationSourceStatus in ReplicationSourceStatus
@@ -179,6 +276,33 @@ type ReplicationSourceResticStatus struct {
 	// lastPruned in the object holding the time of last pruned
 	//+optional
 	LastPruned *metav1.Time `json:"lastPruned,omitempty"`
+	// snapshots lists the most recent snapshots that this source has made in
+	// the repository (newest first) as of the last completed backup. The list
+	// is truncated to a bounded number of entries.
+	//+optional
+	Snapshots []ResticSnapshot `json:"snapshots,omitempty"`
+	// repositoryState records whether the repository was found or created
+	// during the most recent synchronization.
+	//+optional
+	RepositoryState ResticRepositoryStateType `json:"repositoryState,omitempty"`
+}
+
+// ReplicationSourceRsyncTLSSpec defines the configuration when using
+// rsync-over-TLS replication.
+type ReplicationSourceRsyncTLSSpec struct {
+	ReplicationSourceVolumeOptions `json:",inline"`
+	// keySecret is the name of a Secret that contains the TLS pre-shared key
+	// (in the field "psk.txt") used to authenticate with the destination.
+	//+optional
+	KeySecret *string `json:"keySecret,omitempty"`
+	// address is the remote address to connect to for replication.
+	//+optional
+	Address *string `json:"address,omitempty"`
+	// port is the TCP port to connect to for replication. Defaults to 8000.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	//+optional
+	Port *int32 `json:"port,omitempty"`
 }
 
 // ReplicationSourceSpec defines the desired state of ReplicationSource
@@ -195,6 +319,10 @@ type ReplicationSourceSpec struct {
 	// rclone defines the configuration when using Rclone-based replication.
 	//+optional
 	Rclone *ReplicationSourceRcloneSpec `json:"rclone,omitempty"`
+	// rsyncTLS defines the configuration when using rsync-over-TLS
+	// replication.
+	//+optional
+	RsyncTLS *ReplicationSourceRsyncTLSSpec `json:"rsyncTLS,omitempty"`
 	// restic defines the configuration when using Restic-based replication.
 	//+optional
 	Restic *ReplicationSourceResticSpec `json:"restic,omitempty"`
@@ -202,6 +330,19 @@ type ReplicationSourceSpec struct {
 	// provider.
 	//+optional
 	External *ReplicationSourceExternalSpec `json:"external,omitempty"`
+	// deletionPolicy determines whether the data that has been replicated
+	// outside of the cluster (e.g., the contents of a restic repository) and
+	// the SSH keys Secret that was copied from the destination are removed
+	// when the ReplicationSource is deleted. If not specified, they are kept
+	// and the ReplicationSource is deleted immediately.
+	//+optional
+	DeletionPolicy DeletionPolicyType `json:"deletionPolicy,omitempty"`
+	// moverServiceAccount is the name of an existing ServiceAccount (in the
+	// same namespace) that the data mover runs as, e.g., to obtain cloud
+	// credentials via workload identity or to supply imagePullSecrets. If not
+	// specified, a ServiceAccount is created for the mover.
+	//+optional
+	MoverServiceAccount *string `json:"moverServiceAccount,omitempty"`
 	// paused can be used to temporarily stop replication. Defaults to "false".
 	//+optional
 	Paused bool `json:"paused,omitempty"`
@@ -218,6 +359,11 @@ type ReplicationSourceRsyncStatus struct {
 	// connections.
 	//+optional
 	Address *string `json:"address,omitempty"`
+	// addresses lists all of the addresses (e.g., both IPv4 and IPv6 for a
+	// dual-stack Service) at which incoming SSH replication connections are
+	// accepted. The first entry matches address.
+	//+optional
+	Addresses []string `json:"addresses,omitempty"`
 	// port is the SSH port to connect to for incoming SSH replication
 	// connections.
 	//+optional
@@ -240,6 +386,10 @@ type ReplicationSourceStatus struct {
 	// lastManualSync is set to the last spec.trigger.manual when the manual sync is done.
 	//+optional
 	LastManualSync string `json:"lastManualSync,omitempty"`
+	// selectedCopyMethod is the copy method that is in use when copyMethod is
+	// Auto.
+	//+optional
+	SelectedCopyMethod CopyMethodType `json:"selectedCopyMethod,omitempty"`
 	// rsync contains status information for Rsync-based replication.
 	Rsync *ReplicationSourceRsyncStatus `json:"rsync,omitempty"`
 	// external contains provider-specific status information. For more details,
@@ -252,6 +402,17 @@ type ReplicationSourceStatus struct {
 	Conditions status.Conditions `json:"conditions,omitempty"`
 	// restic contains status information for Restic-based replication.
 	Restic *ReplicationSourceResticStatus `json:"restic,omitempty"`
+	// rclone contains status information for Rclone-based replication.
+	//+optional
+	Rclone *ReplicationSourceRcloneStatus `json:"rclone,omitempty"`
+}
+
+// ReplicationSourceRcloneStatus defines the status of an Rclone source.
+type ReplicationSourceRcloneStatus struct {
+	// lastBisyncConflicts is the number of files that were changed both in the
+	// volume and on the remote during the most recent bidirectional sync.
+	//+optional
+	LastBisyncConflicts *int32 `json:"lastBisyncConflicts,omitempty"`
 }
 
 // ReplicationSource defines the source for a replicated volume
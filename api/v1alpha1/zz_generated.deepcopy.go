@@ -39,11 +39,181 @@ package v1alpha1
 
 import (
 	"github.com/operator-framework/operator-lib/status"
-	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RcloneAzureBlobBackend) DeepCopyInto(out *RcloneAzureBlobBackend) {
+	*out = *in
+	if in.Key != nil {
+		in, out := &in.Key, &out.Key
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RcloneAzureBlobBackend.
+func (in *RcloneAzureBlobBackend) DeepCopy() *RcloneAzureBlobBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(RcloneAzureBlobBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RcloneBackendSpec) DeepCopyInto(out *RcloneBackendSpec) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(RcloneS3Backend)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AzureBlob != nil {
+		in, out := &in.AzureBlob, &out.AzureBlob
+		*out = new(RcloneAzureBlobBackend)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		*out = new(RcloneGCSBackend)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SFTP != nil {
+		in, out := &in.SFTP, &out.SFTP
+		*out = new(RcloneSFTPBackend)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Local != nil {
+		in, out := &in.Local, &out.Local
+		*out = new(RcloneLocalBackend)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RcloneBackendSpec.
+func (in *RcloneBackendSpec) DeepCopy() *RcloneBackendSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RcloneBackendSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RcloneGCSBackend) DeepCopyInto(out *RcloneGCSBackend) {
+	*out = *in
+	if in.ServiceAccountCredentials != nil {
+		in, out := &in.ServiceAccountCredentials, &out.ServiceAccountCredentials
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProjectNumber != nil {
+		in, out := &in.ProjectNumber, &out.ProjectNumber
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RcloneGCSBackend.
+func (in *RcloneGCSBackend) DeepCopy() *RcloneGCSBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(RcloneGCSBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RcloneLocalBackend) DeepCopyInto(out *RcloneLocalBackend) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RcloneLocalBackend.
+func (in *RcloneLocalBackend) DeepCopy() *RcloneLocalBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(RcloneLocalBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RcloneS3Backend) DeepCopyInto(out *RcloneS3Backend) {
+	*out = *in
+	if in.Provider != nil {
+		in, out := &in.Provider, &out.Provider
+		*out = new(string)
+		**out = **in
+	}
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(string)
+		**out = **in
+	}
+	if in.Region != nil {
+		in, out := &in.Region, &out.Region
+		*out = new(string)
+		**out = **in
+	}
+	if in.AccessKeyID != nil {
+		in, out := &in.AccessKeyID, &out.AccessKeyID
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretAccessKey != nil {
+		in, out := &in.SecretAccessKey, &out.SecretAccessKey
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RcloneS3Backend.
+func (in *RcloneS3Backend) DeepCopy() *RcloneS3Backend {
+	if in == nil {
+		return nil
+	}
+	out := new(RcloneS3Backend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RcloneSFTPBackend) DeepCopyInto(out *RcloneSFTPBackend) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PrivateKey != nil {
+		in, out := &in.PrivateKey, &out.PrivateKey
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RcloneSFTPBackend.
+func (in *RcloneSFTPBackend) DeepCopy() *RcloneSFTPBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(RcloneSFTPBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicationDestination) DeepCopyInto(out *ReplicationDestination) {
 	*out = *in
@@ -97,6 +267,56 @@ func (in *ReplicationDestinationExternalSpec) DeepCopy() *ReplicationDestination
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationDestinationLatestPVCSpec) DeepCopyInto(out *ReplicationDestinationLatestPVCSpec) {
+	*out = *in
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationLatestPVCSpec.
+func (in *ReplicationDestinationLatestPVCSpec) DeepCopy() *ReplicationDestinationLatestPVCSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationDestinationLatestPVCSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationDestinationLatestPVCStatus) DeepCopyInto(out *ReplicationDestinationLatestPVCStatus) {
+	*out = *in
+	if in.SourceImage != nil {
+		in, out := &in.SourceImage, &out.SourceImage
+		*out = new(corev1.TypedLocalObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationLatestPVCStatus.
+func (in *ReplicationDestinationLatestPVCStatus) DeepCopy() *ReplicationDestinationLatestPVCStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationDestinationLatestPVCStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicationDestinationList) DeepCopyInto(out *ReplicationDestinationList) {
 	*out = *in
@@ -148,6 +368,11 @@ func (in *ReplicationDestinationRcloneSpec) DeepCopyInto(out *ReplicationDestina
 		*out = new(string)
 		**out = **in
 	}
+	if in.RcloneBackend != nil {
+		in, out := &in.RcloneBackend, &out.RcloneBackend
+		*out = new(RcloneBackendSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationRcloneSpec.
@@ -160,10 +385,60 @@ func (in *ReplicationDestinationRcloneSpec) DeepCopy() *ReplicationDestinationRc
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationDestinationRcloneStatus) DeepCopyInto(out *ReplicationDestinationRcloneStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationRcloneStatus.
+func (in *ReplicationDestinationRcloneStatus) DeepCopy() *ReplicationDestinationRcloneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationDestinationRcloneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicationDestinationResticSpec) DeepCopyInto(out *ReplicationDestinationResticSpec) {
 	*out = *in
 	in.ReplicationDestinationVolumeOptions.DeepCopyInto(&out.ReplicationDestinationVolumeOptions)
+	if in.Initialize != nil {
+		in, out := &in.Initialize, &out.Initialize
+		*out = new(ResticInitializePolicyType)
+		**out = **in
+	}
+	if in.Mode != nil {
+		in, out := &in.Mode, &out.Mode
+		*out = new(ResticDestinationModeType)
+		**out = **in
+	}
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(string)
+		**out = **in
+	}
+	if in.TargetCleanup != nil {
+		in, out := &in.TargetCleanup, &out.TargetCleanup
+		*out = new(ResticTargetCleanupType)
+		**out = **in
+	}
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TargetSubdirectory != nil {
+		in, out := &in.TargetSubdirectory, &out.TargetSubdirectory
+		*out = new(string)
+		**out = **in
+	}
 	if in.CacheCapacity != nil {
 		in, out := &in.CacheCapacity, &out.CacheCapacity
 		x := (*in).DeepCopy()
@@ -176,9 +451,10 @@ func (in *ReplicationDestinationResticSpec) DeepCopyInto(out *ReplicationDestina
 	}
 	if in.CacheAccessModes != nil {
 		in, out := &in.CacheAccessModes, &out.CacheAccessModes
-		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
 		copy(*out, *in)
 	}
+	in.ResticJobOptions.DeepCopyInto(&out.ResticJobOptions)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationResticSpec.
@@ -191,6 +467,26 @@ func (in *ReplicationDestinationResticSpec) DeepCopy() *ReplicationDestinationRe
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationDestinationResticStatus) DeepCopyInto(out *ReplicationDestinationResticStatus) {
+	*out = *in
+	if in.LastVerification != nil {
+		in, out := &in.LastVerification, &out.LastVerification
+		*out = new(ResticVerificationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationResticStatus.
+func (in *ReplicationDestinationResticStatus) DeepCopy() *ReplicationDestinationResticStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationDestinationResticStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicationDestinationRsyncSpec) DeepCopyInto(out *ReplicationDestinationRsyncSpec) {
 	*out = *in
@@ -200,11 +496,22 @@ func (in *ReplicationDestinationRsyncSpec) DeepCopyInto(out *ReplicationDestinat
 		*out = new(string)
 		**out = **in
 	}
+	if in.SSHKeyType != nil {
+		in, out := &in.SSHKeyType, &out.SSHKeyType
+		*out = new(SSHKeyType)
+		**out = **in
+	}
+	if in.SSHKeyRotation != nil {
+		in, out := &in.SSHKeyRotation, &out.SSHKeyRotation
+		*out = new(SSHKeyRotationSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ServiceType != nil {
 		in, out := &in.ServiceType, &out.ServiceType
-		*out = new(v1.ServiceType)
+		*out = new(corev1.ServiceType)
 		**out = **in
 	}
+	in.RsyncServiceOptions.DeepCopyInto(&out.RsyncServiceOptions)
 	if in.Address != nil {
 		in, out := &in.Address, &out.Address
 		*out = new(string)
@@ -225,6 +532,16 @@ func (in *ReplicationDestinationRsyncSpec) DeepCopyInto(out *ReplicationDestinat
 		*out = new(string)
 		**out = **in
 	}
+	if in.ConnectionTimeoutSeconds != nil {
+		in, out := &in.ConnectionTimeoutSeconds, &out.ConnectionTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.TransferTimeoutSeconds != nil {
+		in, out := &in.TransferTimeoutSeconds, &out.TransferTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationRsyncSpec.
@@ -250,6 +567,11 @@ func (in *ReplicationDestinationRsyncStatus) DeepCopyInto(out *ReplicationDestin
 		*out = new(string)
 		**out = **in
 	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Port != nil {
 		in, out := &in.Port, &out.Port
 		*out = new(int32)
@@ -267,6 +589,67 @@ func (in *ReplicationDestinationRsyncStatus) DeepCopy() *ReplicationDestinationR
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationDestinationRsyncTLSSpec) DeepCopyInto(out *ReplicationDestinationRsyncTLSSpec) {
+	*out = *in
+	in.ReplicationDestinationVolumeOptions.DeepCopyInto(&out.ReplicationDestinationVolumeOptions)
+	if in.KeySecret != nil {
+		in, out := &in.KeySecret, &out.KeySecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.ServiceType != nil {
+		in, out := &in.ServiceType, &out.ServiceType
+		*out = new(corev1.ServiceType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationRsyncTLSSpec.
+func (in *ReplicationDestinationRsyncTLSSpec) DeepCopy() *ReplicationDestinationRsyncTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationDestinationRsyncTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationDestinationRsyncTLSStatus) DeepCopyInto(out *ReplicationDestinationRsyncTLSStatus) {
+	*out = *in
+	if in.Address != nil {
+		in, out := &in.Address, &out.Address
+		*out = new(string)
+		**out = **in
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.KeySecret != nil {
+		in, out := &in.KeySecret, &out.KeySecret
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationRsyncTLSStatus.
+func (in *ReplicationDestinationRsyncTLSStatus) DeepCopy() *ReplicationDestinationRsyncTLSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationDestinationRsyncTLSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicationDestinationSpec) DeepCopyInto(out *ReplicationDestinationSpec) {
 	*out = *in
@@ -285,6 +668,11 @@ func (in *ReplicationDestinationSpec) DeepCopyInto(out *ReplicationDestinationSp
 		*out = new(ReplicationDestinationRcloneSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RsyncTLS != nil {
+		in, out := &in.RsyncTLS, &out.RsyncTLS
+		*out = new(ReplicationDestinationRsyncTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Restic != nil {
 		in, out := &in.Restic, &out.Restic
 		*out = new(ReplicationDestinationResticSpec)
@@ -295,6 +683,16 @@ func (in *ReplicationDestinationSpec) DeepCopyInto(out *ReplicationDestinationSp
 		*out = new(ReplicationDestinationExternalSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LatestPVC != nil {
+		in, out := &in.LatestPVC, &out.LatestPVC
+		*out = new(ReplicationDestinationLatestPVCSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MoverServiceAccount != nil {
+		in, out := &in.MoverServiceAccount, &out.MoverServiceAccount
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationSpec.
@@ -316,7 +714,7 @@ func (in *ReplicationDestinationStatus) DeepCopyInto(out *ReplicationDestination
 	}
 	if in.LastSyncDuration != nil {
 		in, out := &in.LastSyncDuration, &out.LastSyncDuration
-		*out = new(metav1.Duration)
+		*out = new(v1.Duration)
 		**out = **in
 	}
 	if in.NextSyncTime != nil {
@@ -325,7 +723,12 @@ func (in *ReplicationDestinationStatus) DeepCopyInto(out *ReplicationDestination
 	}
 	if in.LatestImage != nil {
 		in, out := &in.LatestImage, &out.LatestImage
-		*out = new(v1.TypedLocalObjectReference)
+		*out = new(corev1.TypedLocalObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LatestPVC != nil {
+		in, out := &in.LatestPVC, &out.LatestPVC
+		*out = new(ReplicationDestinationLatestPVCStatus)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Rsync != nil {
@@ -333,6 +736,21 @@ func (in *ReplicationDestinationStatus) DeepCopyInto(out *ReplicationDestination
 		*out = new(ReplicationDestinationRsyncStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Restic != nil {
+		in, out := &in.Restic, &out.Restic
+		*out = new(ReplicationDestinationResticStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RsyncTLS != nil {
+		in, out := &in.RsyncTLS, &out.RsyncTLS
+		*out = new(ReplicationDestinationRsyncTLSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rclone != nil {
+		in, out := &in.Rclone, &out.Rclone
+		*out = new(ReplicationDestinationRcloneStatus)
+		**out = **in
+	}
 	if in.External != nil {
 		in, out := &in.External, &out.External
 		*out = make(map[string]string, len(*in))
@@ -394,14 +812,24 @@ func (in *ReplicationDestinationVolumeOptions) DeepCopyInto(out *ReplicationDest
 	}
 	if in.AccessModes != nil {
 		in, out := &in.AccessModes, &out.AccessModes
-		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
 		copy(*out, *in)
 	}
+	if in.VolumeMode != nil {
+		in, out := &in.VolumeMode, &out.VolumeMode
+		*out = new(corev1.PersistentVolumeMode)
+		**out = **in
+	}
 	if in.VolumeSnapshotClassName != nil {
 		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
 		*out = new(string)
 		**out = **in
 	}
+	if in.SnapshotTimeoutSeconds != nil {
+		in, out := &in.SnapshotTimeoutSeconds, &out.SnapshotTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
 	if in.DestinationPVC != nil {
 		in, out := &in.DestinationPVC, &out.DestinationPVC
 		*out = new(string)
@@ -523,6 +951,36 @@ func (in *ReplicationSourceRcloneSpec) DeepCopyInto(out *ReplicationSourceRclone
 		*out = new(string)
 		**out = **in
 	}
+	if in.RcloneBackend != nil {
+		in, out := &in.RcloneBackend, &out.RcloneBackend
+		*out = new(RcloneBackendSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RcloneMode != nil {
+		in, out := &in.RcloneMode, &out.RcloneMode
+		*out = new(RcloneModeType)
+		**out = **in
+	}
+	if in.RcloneBackupRetention != nil {
+		in, out := &in.RcloneBackupRetention, &out.RcloneBackupRetention
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BisyncConflictResolution != nil {
+		in, out := &in.BisyncConflictResolution, &out.BisyncConflictResolution
+		*out = new(RcloneConflictResolutionType)
+		**out = **in
+	}
+	if in.BisyncStateCapacity != nil {
+		in, out := &in.BisyncStateCapacity, &out.BisyncStateCapacity
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.BisyncStateStorageClassName != nil {
+		in, out := &in.BisyncStateStorageClassName, &out.BisyncStateStorageClassName
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceRcloneSpec.
@@ -535,6 +993,26 @@ func (in *ReplicationSourceRcloneSpec) DeepCopy() *ReplicationSourceRcloneSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationSourceRcloneStatus) DeepCopyInto(out *ReplicationSourceRcloneStatus) {
+	*out = *in
+	if in.LastBisyncConflicts != nil {
+		in, out := &in.LastBisyncConflicts, &out.LastBisyncConflicts
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceRcloneStatus.
+func (in *ReplicationSourceRcloneStatus) DeepCopy() *ReplicationSourceRcloneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationSourceRcloneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicationSourceResticSpec) DeepCopyInto(out *ReplicationSourceResticSpec) {
 	*out = *in
@@ -549,6 +1027,11 @@ func (in *ReplicationSourceResticSpec) DeepCopyInto(out *ReplicationSourceRestic
 		*out = new(ResticRetainPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Initialize != nil {
+		in, out := &in.Initialize, &out.Initialize
+		*out = new(ResticInitializePolicyType)
+		**out = **in
+	}
 	if in.CacheCapacity != nil {
 		in, out := &in.CacheCapacity, &out.CacheCapacity
 		x := (*in).DeepCopy()
@@ -561,9 +1044,10 @@ func (in *ReplicationSourceResticSpec) DeepCopyInto(out *ReplicationSourceRestic
 	}
 	if in.CacheAccessModes != nil {
 		in, out := &in.CacheAccessModes, &out.CacheAccessModes
-		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
 		copy(*out, *in)
 	}
+	in.ResticJobOptions.DeepCopyInto(&out.ResticJobOptions)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceResticSpec.
@@ -583,6 +1067,13 @@ func (in *ReplicationSourceResticStatus) DeepCopyInto(out *ReplicationSourceRest
 		in, out := &in.LastPruned, &out.LastPruned
 		*out = (*in).DeepCopy()
 	}
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]ResticSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceResticStatus.
@@ -604,11 +1095,22 @@ func (in *ReplicationSourceRsyncSpec) DeepCopyInto(out *ReplicationSourceRsyncSp
 		*out = new(string)
 		**out = **in
 	}
+	if in.SSHKeyType != nil {
+		in, out := &in.SSHKeyType, &out.SSHKeyType
+		*out = new(SSHKeyType)
+		**out = **in
+	}
+	if in.SSHKeyRotation != nil {
+		in, out := &in.SSHKeyRotation, &out.SSHKeyRotation
+		*out = new(SSHKeyRotationSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ServiceType != nil {
 		in, out := &in.ServiceType, &out.ServiceType
-		*out = new(v1.ServiceType)
+		*out = new(corev1.ServiceType)
 		**out = **in
 	}
+	in.RsyncServiceOptions.DeepCopyInto(&out.RsyncServiceOptions)
 	if in.Address != nil {
 		in, out := &in.Address, &out.Address
 		*out = new(string)
@@ -629,6 +1131,21 @@ func (in *ReplicationSourceRsyncSpec) DeepCopyInto(out *ReplicationSourceRsyncSp
 		*out = new(string)
 		**out = **in
 	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(RsyncProxySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SyncTimeoutSeconds != nil {
+		in, out := &in.SyncTimeoutSeconds, &out.SyncTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Parallelism != nil {
+		in, out := &in.Parallelism, &out.Parallelism
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceRsyncSpec.
@@ -654,6 +1171,11 @@ func (in *ReplicationSourceRsyncStatus) DeepCopyInto(out *ReplicationSourceRsync
 		*out = new(string)
 		**out = **in
 	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Port != nil {
 		in, out := &in.Port, &out.Port
 		*out = new(int32)
@@ -671,6 +1193,37 @@ func (in *ReplicationSourceRsyncStatus) DeepCopy() *ReplicationSourceRsyncStatus
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationSourceRsyncTLSSpec) DeepCopyInto(out *ReplicationSourceRsyncTLSSpec) {
+	*out = *in
+	in.ReplicationSourceVolumeOptions.DeepCopyInto(&out.ReplicationSourceVolumeOptions)
+	if in.KeySecret != nil {
+		in, out := &in.KeySecret, &out.KeySecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.Address != nil {
+		in, out := &in.Address, &out.Address
+		*out = new(string)
+		**out = **in
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceRsyncTLSSpec.
+func (in *ReplicationSourceRsyncTLSSpec) DeepCopy() *ReplicationSourceRsyncTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationSourceRsyncTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicationSourceSpec) DeepCopyInto(out *ReplicationSourceSpec) {
 	*out = *in
@@ -689,6 +1242,11 @@ func (in *ReplicationSourceSpec) DeepCopyInto(out *ReplicationSourceSpec) {
 		*out = new(ReplicationSourceRcloneSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RsyncTLS != nil {
+		in, out := &in.RsyncTLS, &out.RsyncTLS
+		*out = new(ReplicationSourceRsyncTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Restic != nil {
 		in, out := &in.Restic, &out.Restic
 		*out = new(ReplicationSourceResticSpec)
@@ -699,6 +1257,11 @@ func (in *ReplicationSourceSpec) DeepCopyInto(out *ReplicationSourceSpec) {
 		*out = new(ReplicationSourceExternalSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MoverServiceAccount != nil {
+		in, out := &in.MoverServiceAccount, &out.MoverServiceAccount
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceSpec.
@@ -720,7 +1283,7 @@ func (in *ReplicationSourceStatus) DeepCopyInto(out *ReplicationSourceStatus) {
 	}
 	if in.LastSyncDuration != nil {
 		in, out := &in.LastSyncDuration, &out.LastSyncDuration
-		*out = new(metav1.Duration)
+		*out = new(v1.Duration)
 		**out = **in
 	}
 	if in.NextSyncTime != nil {
@@ -751,6 +1314,11 @@ func (in *ReplicationSourceStatus) DeepCopyInto(out *ReplicationSourceStatus) {
 		*out = new(ReplicationSourceResticStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Rclone != nil {
+		in, out := &in.Rclone, &out.Rclone
+		*out = new(ReplicationSourceRcloneStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceStatus.
@@ -798,7 +1366,7 @@ func (in *ReplicationSourceVolumeOptions) DeepCopyInto(out *ReplicationSourceVol
 	}
 	if in.AccessModes != nil {
 		in, out := &in.AccessModes, &out.AccessModes
-		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
 		copy(*out, *in)
 	}
 	if in.VolumeSnapshotClassName != nil {
@@ -806,6 +1374,11 @@ func (in *ReplicationSourceVolumeOptions) DeepCopyInto(out *ReplicationSourceVol
 		*out = new(string)
 		**out = **in
 	}
+	if in.SnapshotTimeoutSeconds != nil {
+		in, out := &in.SnapshotTimeoutSeconds, &out.SnapshotTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceVolumeOptions.
@@ -818,6 +1391,36 @@ func (in *ReplicationSourceVolumeOptions) DeepCopy() *ReplicationSourceVolumeOpt
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResticJobOptions) DeepCopyInto(out *ResticJobOptions) {
+	*out = *in
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AttemptTimeoutSeconds != nil {
+		in, out := &in.AttemptTimeoutSeconds, &out.AttemptTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SyncTimeoutSeconds != nil {
+		in, out := &in.SyncTimeoutSeconds, &out.SyncTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResticJobOptions.
+func (in *ResticJobOptions) DeepCopy() *ResticJobOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(ResticJobOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResticRetainPolicy) DeepCopyInto(out *ResticRetainPolicy) {
 	*out = *in
@@ -862,3 +1465,160 @@ func (in *ResticRetainPolicy) DeepCopy() *ResticRetainPolicy {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResticSnapshot) DeepCopyInto(out *ResticSnapshot) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResticSnapshot.
+func (in *ResticSnapshot) DeepCopy() *ResticSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(ResticSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResticVerificationStatus) DeepCopyInto(out *ResticVerificationStatus) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResticVerificationStatus.
+func (in *ResticVerificationStatus) DeepCopy() *ResticVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ResticVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RsyncProxySpec) DeepCopyInto(out *RsyncProxySpec) {
+	*out = *in
+	if in.JumpHost != nil {
+		in, out := &in.JumpHost, &out.JumpHost
+		*out = new(string)
+		**out = **in
+	}
+	if in.JumpHostKeys != nil {
+		in, out := &in.JumpHostKeys, &out.JumpHostKeys
+		*out = new(string)
+		**out = **in
+	}
+	if in.HTTPProxy != nil {
+		in, out := &in.HTTPProxy, &out.HTTPProxy
+		*out = new(string)
+		**out = **in
+	}
+	if in.HTTPProxyCredentials != nil {
+		in, out := &in.HTTPProxyCredentials, &out.HTTPProxyCredentials
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RsyncProxySpec.
+func (in *RsyncProxySpec) DeepCopy() *RsyncProxySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RsyncProxySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RsyncServiceOptions) DeepCopyInto(out *RsyncServiceOptions) {
+	*out = *in
+	if in.ServiceAnnotations != nil {
+		in, out := &in.ServiceAnnotations, &out.ServiceAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ServiceLabels != nil {
+		in, out := &in.ServiceLabels, &out.ServiceLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPFamilyPolicy != nil {
+		in, out := &in.IPFamilyPolicy, &out.IPFamilyPolicy
+		*out = new(corev1.IPFamilyPolicyType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RsyncServiceOptions.
+func (in *RsyncServiceOptions) DeepCopy() *RsyncServiceOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(RsyncServiceOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHKeyRotationSpec) DeepCopyInto(out *SSHKeyRotationSpec) {
+	*out = *in
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHKeyRotationSpec.
+func (in *SSHKeyRotationSpec) DeepCopy() *SSHKeyRotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHKeyRotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
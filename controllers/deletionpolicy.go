@@ -0,0 +1,238 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	"github.com/operator-framework/operator-lib/status"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/mover"
+)
+
+// Finalizer that holds the deletion of a ReplicationSource or
+// ReplicationDestination until its deletionPolicy has been carried out
+const deletionFinalizer = "volsync.backube/deletion-policy"
+
+// updateFinalizer adds the deletion finalizer to an object that has a
+// deletionPolicy and removes it from one that doesn't. The object is only
+// updated if the finalizer changes.
+func updateFinalizer(ctx context.Context, c client.Client, obj client.Object,
+	policy volsyncv1alpha1.DeletionPolicyType) error {
+	want := policy != ""
+	if want == ctrlutil.ContainsFinalizer(obj, deletionFinalizer) {
+		return nil
+	}
+	if want {
+		ctrlutil.AddFinalizer(obj, deletionFinalizer)
+	} else {
+		ctrlutil.RemoveFinalizer(obj, deletionFinalizer)
+	}
+	return c.Update(ctx, obj)
+}
+
+// finalize carries out the deletionPolicy of a ReplicationSource that is being
+// deleted. The finalizer is removed once it is complete.
+func (r *ReplicationSourceReconciler) finalize(ctx context.Context,
+	inst *volsyncv1alpha1.ReplicationSource, logger logr.Logger) (ctrl.Result, error) {
+	if !ctrlutil.ContainsFinalizer(inst, deletionFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	if inst.Spec.DeletionPolicy == volsyncv1alpha1.DeletionPolicyDelete {
+		var dataMover mover.Mover
+		for _, builder := range mover.Catalog {
+			if candidate, err := builder.FromSource(r.Client, logger, inst); err == nil && candidate != nil {
+				dataMover = candidate
+			}
+		}
+		result, err := finalizeMover(ctx, dataMover)
+		if err == nil && result.Completed {
+			err = deleteCopiedSSHKeys(ctx, r.Client, inst, logger)
+		}
+		if err != nil {
+			if inst.Status == nil {
+				inst.Status = &volsyncv1alpha1.ReplicationSourceStatus{}
+			}
+			inst.Status.Conditions.SetCondition(deletionFailedCondition(err))
+			if statusErr := r.Client.Status().Update(ctx, inst); statusErr != nil {
+				logger.Error(statusErr, "unable to update status")
+			}
+			return ctrl.Result{}, err
+		}
+		if !result.Completed {
+			return result.ReconcileResult(), nil
+		}
+	}
+	logger.Info("deletion policy complete", "deletionPolicy", inst.Spec.DeletionPolicy)
+	ctrlutil.RemoveFinalizer(inst, deletionFinalizer)
+	return ctrl.Result{}, r.Client.Update(ctx, inst)
+}
+
+// finalize carries out the deletionPolicy of a ReplicationDestination that is
+// being deleted. The finalizer is removed once it is complete.
+func (r *ReplicationDestinationReconciler) finalize(ctx context.Context,
+	inst *volsyncv1alpha1.ReplicationDestination, logger logr.Logger) (ctrl.Result, error) {
+	if !ctrlutil.ContainsFinalizer(inst, deletionFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	var result = mover.Complete()
+	var err error
+	switch inst.Spec.DeletionPolicy {
+	case volsyncv1alpha1.DeletionPolicyRetain:
+		// The images are owned by the ReplicationDestination, so the most
+		// recent one must be released to keep it from being garbage collected
+		if inst.Status != nil {
+			err = releaseImage(ctx, r.Client, inst, inst.Status.LatestImage, logger)
+		}
+	case volsyncv1alpha1.DeletionPolicyDelete:
+		var dataMover mover.Mover
+		for _, builder := range mover.Catalog {
+			if candidate, e := builder.FromDestination(r.Client, logger, inst); e == nil && candidate != nil {
+				dataMover = candidate
+			}
+		}
+		result, err = finalizeMover(ctx, dataMover)
+	}
+	if err != nil {
+		if inst.Status == nil {
+			inst.Status = &volsyncv1alpha1.ReplicationDestinationStatus{}
+		}
+		inst.Status.Conditions.SetCondition(deletionFailedCondition(err))
+		if statusErr := r.Client.Status().Update(ctx, inst); statusErr != nil {
+			logger.Error(statusErr, "unable to update status")
+		}
+		return ctrl.Result{}, err
+	}
+	if !result.Completed {
+		return result.ReconcileResult(), nil
+	}
+	logger.Info("deletion policy complete", "deletionPolicy", inst.Spec.DeletionPolicy)
+	ctrlutil.RemoveFinalizer(inst, deletionFinalizer)
+	return ctrl.Result{}, r.Client.Update(ctx, inst)
+}
+
+// finalizeMover removes the data that the mover keeps outside of the cluster.
+// Movers that don't keep any are complete right away.
+func finalizeMover(ctx context.Context, dataMover mover.Mover) (mover.Result, error) {
+	finalizer, ok := dataMover.(mover.Finalizer)
+	if !ok {
+		return mover.Complete(), nil
+	}
+	return finalizer.Finalize(ctx)
+}
+
+func deletionFailedCondition(err error) status.Condition {
+	return status.Condition{
+		Type:    volsyncv1alpha1.ConditionReconciled,
+		Status:  corev1.ConditionFalse,
+		Reason:  volsyncv1alpha1.ReconciledReasonError,
+		Message: "unable to carry out deletionPolicy: " + err.Error(),
+	}
+}
+
+// deleteCopiedSSHKeys removes the SSH keys Secret of a ReplicationSource if it
+// was copied from the destination and no other ReplicationSource uses it
+func deleteCopiedSSHKeys(ctx context.Context, c client.Client,
+	inst *volsyncv1alpha1.ReplicationSource, logger logr.Logger) error {
+	if inst.Spec.Rsync == nil || inst.Spec.Rsync.SSHKeys == nil {
+		return nil
+	}
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: *inst.Spec.Rsync.SSHKeys, Namespace: inst.Namespace}, secret)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		logger.Error(err, "unable to get SSH keys Secret")
+		return err
+	}
+	if _, ok := secret.Labels[volsyncv1alpha1.CopiedSSHKeysLabel]; !ok {
+		return nil
+	}
+
+	rsList := &volsyncv1alpha1.ReplicationSourceList{}
+	if err := c.List(ctx, rsList, client.InNamespace(inst.Namespace)); err != nil {
+		logger.Error(err, "unable to list ReplicationSources")
+		return err
+	}
+	for _, rs := range rsList.Items {
+		if rs.UID != inst.UID && rs.Spec.Rsync != nil && rs.Spec.Rsync.SSHKeys != nil &&
+			*rs.Spec.Rsync.SSHKeys == secret.Name {
+			logger.Info("SSH keys Secret is still in use", "Secret", secret.Name, "replicationsource", rs.Name)
+			return nil
+		}
+	}
+	if err := c.Delete(ctx, secret); err != nil && !kerrors.IsNotFound(err) {
+		logger.Error(err, "unable to delete SSH keys Secret")
+		return err
+	}
+	logger.Info("deleted copied SSH keys Secret", "Secret", secret.Name)
+	return nil
+}
+
+// releaseImage removes the owner's references from the image so that it is
+// kept after the owner is deleted
+func releaseImage(ctx context.Context, c client.Client, owner metav1.Object,
+	image *corev1.TypedLocalObjectReference, logger logr.Logger) error {
+	if image == nil {
+		return nil
+	}
+	var obj client.Object
+	switch image.Kind {
+	case "VolumeSnapshot":
+		obj = &snapv1.VolumeSnapshot{}
+	case "PersistentVolumeClaim":
+		obj = &corev1.PersistentVolumeClaim{}
+	default:
+		return nil
+	}
+	l := logger.WithValues("image", image)
+	if err := c.Get(ctx, types.NamespacedName{Name: image.Name, Namespace: owner.GetNamespace()}, obj); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		l.Error(err, "unable to get image")
+		return err
+	}
+	refs := []metav1.OwnerReference{}
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID != owner.GetUID() {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == len(obj.GetOwnerReferences()) {
+		return nil
+	}
+	obj.SetOwnerReferences(refs)
+	if err := c.Update(ctx, obj); err != nil {
+		l.Error(err, "unable to release image")
+		return err
+	}
+	l.Info("image retained")
+	return nil
+}
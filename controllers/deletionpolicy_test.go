@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+)
+
+var _ = Describe("Deletion policy", func() {
+	var ctx = context.Background()
+	var namespace *corev1.Namespace
+
+	// Waits for the object to have (or not have) the deletion finalizer
+	hasFinalizer := func(obj client.Object) func() bool {
+		return func() bool {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+				return false
+			}
+			return ctrlutil.ContainsFinalizer(obj, deletionFinalizer)
+		}
+	}
+	isGone := func(obj client.Object) func() bool {
+		return func() bool {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+			return kerrors.IsNotFound(err)
+		}
+	}
+
+	BeforeEach(func() {
+		namespace = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "volsync-test-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+	})
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, namespace)).To(Succeed())
+	})
+
+	Context("of a ReplicationSource", func() {
+		var rs *volsyncv1alpha1.ReplicationSource
+		var sshKeys *corev1.Secret
+		BeforeEach(func() {
+			sshKeys = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "keys",
+					Namespace: namespace.Name,
+				},
+			}
+			keysName := sshKeys.Name
+			rs = &volsyncv1alpha1.ReplicationSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rs",
+					Namespace: namespace.Name,
+				},
+				Spec: volsyncv1alpha1.ReplicationSourceSpec{
+					SourcePVC: "missing",
+					Rsync: &volsyncv1alpha1.ReplicationSourceRsyncSpec{
+						SSHKeys: &keysName,
+					},
+				},
+			}
+		})
+		JustBeforeEach(func() {
+			Expect(k8sClient.Create(ctx, sshKeys)).To(Succeed())
+			Expect(k8sClient.Create(ctx, rs)).To(Succeed())
+		})
+
+		When("no deletionPolicy is specified", func() {
+			It("doesn't add a finalizer", func() {
+				Consistently(hasFinalizer(rs), duration, interval).Should(BeFalse())
+			})
+		})
+		When("the deletionPolicy is Delete", func() {
+			BeforeEach(func() {
+				rs.Spec.DeletionPolicy = volsyncv1alpha1.DeletionPolicyDelete
+			})
+			It("removes SSH keys that were copied from the destination", func() {
+				sshKeys.Labels = map[string]string{volsyncv1alpha1.CopiedSSHKeysLabel: "true"}
+				Expect(k8sClient.Update(ctx, sshKeys)).To(Succeed())
+				Eventually(hasFinalizer(rs), maxWait, interval).Should(BeTrue())
+				Expect(k8sClient.Delete(ctx, rs)).To(Succeed())
+				Eventually(isGone(rs), maxWait, interval).Should(BeTrue())
+				Expect(isGone(sshKeys)()).To(BeTrue())
+			})
+			It("keeps SSH keys that were provided by the user", func() {
+				Eventually(hasFinalizer(rs), maxWait, interval).Should(BeTrue())
+				Expect(k8sClient.Delete(ctx, rs)).To(Succeed())
+				Eventually(isGone(rs), maxWait, interval).Should(BeTrue())
+				Expect(isGone(sshKeys)()).To(BeFalse())
+			})
+		})
+	})
+
+	Context("of a ReplicationDestination", func() {
+		var rd *volsyncv1alpha1.ReplicationDestination
+		var image *corev1.PersistentVolumeClaim
+		BeforeEach(func() {
+			rd = &volsyncv1alpha1.ReplicationDestination{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rd",
+					Namespace: namespace.Name,
+				},
+			}
+			image = &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "image",
+					Namespace: namespace.Name,
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			}
+		})
+		JustBeforeEach(func() {
+			Expect(k8sClient.Create(ctx, rd)).To(Succeed())
+			Expect(ctrlutil.SetControllerReference(rd, image, k8sClient.Scheme())).To(Succeed())
+			Expect(k8sClient.Create(ctx, image)).To(Succeed())
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(rd), rd); err != nil {
+					return err
+				}
+				rd.Status = &volsyncv1alpha1.ReplicationDestinationStatus{
+					LatestImage: &corev1.TypedLocalObjectReference{
+						Kind: "PersistentVolumeClaim",
+						Name: image.Name,
+					},
+				}
+				return k8sClient.Status().Update(ctx, rd)
+			}, maxWait, interval).Should(Succeed())
+		})
+
+		When("the deletionPolicy is Retain", func() {
+			BeforeEach(func() {
+				rd.Spec.DeletionPolicy = volsyncv1alpha1.DeletionPolicyRetain
+			})
+			It("releases the latest image", func() {
+				Eventually(hasFinalizer(rd), maxWait, interval).Should(BeTrue())
+				Expect(k8sClient.Delete(ctx, rd)).To(Succeed())
+				Eventually(isGone(rd), maxWait, interval).Should(BeTrue())
+				retained := &corev1.PersistentVolumeClaim{}
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(image), retained)).To(Succeed())
+				Expect(retained.OwnerReferences).To(BeEmpty())
+			})
+		})
+		When("the deletionPolicy is Delete", func() {
+			BeforeEach(func() {
+				rd.Spec.DeletionPolicy = volsyncv1alpha1.DeletionPolicyDelete
+			})
+			It("leaves the latest image to be garbage collected", func() {
+				Eventually(hasFinalizer(rd), maxWait, interval).Should(BeTrue())
+				Expect(k8sClient.Delete(ctx, rd)).To(Succeed())
+				Eventually(isGone(rd), maxWait, interval).Should(BeTrue())
+				retained := &corev1.PersistentVolumeClaim{}
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(image), retained)).To(Succeed())
+				Expect(retained.OwnerReferences).NotTo(BeEmpty())
+			})
+		})
+	})
+})
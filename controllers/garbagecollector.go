@@ -0,0 +1,192 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/utils"
+)
+
+const (
+	// The reasons that a temporary object is collected
+	gcReasonOrphaned = "OwnerNotFound"
+	gcReasonExpired  = "SyncCompleted"
+)
+
+// OrphanCollector periodically removes the temporary objects that were marked
+// for cleanup but outlived the synchronization that created them. This
+// happens if the operator is interrupted before it cleans up or if the owner
+// is deleted (or recreated with the same name) in the middle of a
+// synchronization.
+type OrphanCollector struct {
+	Client client.Client
+	Log    logr.Logger
+	// Interval is the time between collections
+	Interval time.Duration
+	// GracePeriod is how long a temporary object is kept after it is created
+	// and after its owner's synchronization has completed
+	GracePeriod time.Duration
+	// DryRun logs the objects that would be removed instead of removing them
+	DryRun bool
+}
+
+var _ manager.Runnable = &OrphanCollector{}
+var _ manager.LeaderElectionRunnable = &OrphanCollector{}
+
+// gcLists returns lists for each of the types of temporary objects that are
+// collected, keyed by kind
+func gcLists() map[string]client.ObjectList {
+	return map[string]client.ObjectList{
+		"PersistentVolumeClaim": &corev1.PersistentVolumeClaimList{},
+		"VolumeSnapshot":        &snapv1.VolumeSnapshotList{},
+		"Job":                   &batchv1.JobList{},
+	}
+}
+
+// Start runs the collector until the context is canceled
+func (gc *OrphanCollector) Start(ctx context.Context) error {
+	gc.Log.Info("starting", "interval", gc.Interval, "gracePeriod", gc.GracePeriod, "dryRun", gc.DryRun)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := gc.Collect(ctx); err != nil {
+			gc.Log.Error(err, "collection failed")
+		}
+	}, gc.Interval)
+	return nil
+}
+
+// NeedLeaderElection ensures that only one instance of the operator collects
+func (gc *OrphanCollector) NeedLeaderElection() bool { return true }
+
+// Collect removes the stale temporary objects
+func (gc *OrphanCollector) Collect(ctx context.Context) error {
+	lastSync, err := gc.lastSyncByOwner(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for kind, list := range gcLists() {
+		l := gc.Log.WithValues("kind", kind)
+		if err := gc.Client.List(ctx, list, utils.MarkedForCleanup()); err != nil {
+			if meta.IsNoMatchError(err) {
+				// The API (i.e., snapshots) isn't available
+				continue
+			}
+			l.Error(err, "unable to list temporary objects")
+			return err
+		}
+		objs, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		// Each pass reports the objects that are currently stale, so objects
+		// that are kept (e.g., in dry-run mode) aren't counted repeatedly
+		stale := map[string]float64{gcReasonOrphaned: 0, gcReasonExpired: 0}
+		for _, o := range objs {
+			obj, ok := o.(client.Object)
+			if !ok {
+				continue
+			}
+			reason := gc.staleReason(obj, lastSync, now)
+			if reason == "" {
+				continue
+			}
+			stale[reason]++
+			ol := l.WithValues("object", utils.NameFor(obj), "owner", utils.CleanupOwner(obj), "reason", reason)
+			if gc.DryRun {
+				ol.Info("would delete stale temporary object (dry run)")
+				continue
+			}
+			err := gc.Client.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !kerrors.IsNotFound(err) {
+				ol.Error(err, "unable to delete stale temporary object")
+				return err
+			}
+			gcDeletedObjects.WithLabelValues(kind, reason).Inc()
+			ol.Info("deleted stale temporary object")
+		}
+		for reason, count := range stale {
+			gcStaleObjects.WithLabelValues(kind, reason).Set(count)
+		}
+	}
+	return nil
+}
+
+// lastSyncByOwner returns the time of the last completed synchronization of
+// each ReplicationSource and ReplicationDestination, keyed by UID. Owners that
+// haven't completed a synchronization map to nil.
+func (gc *OrphanCollector) lastSyncByOwner(ctx context.Context) (map[types.UID]*metav1.Time, error) {
+	lastSync := map[types.UID]*metav1.Time{}
+	rsList := &volsyncv1alpha1.ReplicationSourceList{}
+	if err := gc.Client.List(ctx, rsList); err != nil {
+		gc.Log.Error(err, "unable to list ReplicationSources")
+		return nil, err
+	}
+	for _, rs := range rsList.Items {
+		lastSync[rs.UID] = nil
+		if rs.Status != nil {
+			lastSync[rs.UID] = rs.Status.LastSyncTime
+		}
+	}
+	rdList := &volsyncv1alpha1.ReplicationDestinationList{}
+	if err := gc.Client.List(ctx, rdList); err != nil {
+		gc.Log.Error(err, "unable to list ReplicationDestinations")
+		return nil, err
+	}
+	for _, rd := range rdList.Items {
+		lastSync[rd.UID] = nil
+		if rd.Status != nil {
+			lastSync[rd.UID] = rd.Status.LastSyncTime
+		}
+	}
+	return lastSync, nil
+}
+
+// staleReason returns why the temporary object should be collected, or "" if
+// it should be kept. Objects are stale if their owner no longer exists or if
+// they were created before the owner's most recent synchronization completed,
+// since they would have been cleaned up at the end of that synchronization.
+func (gc *OrphanCollector) staleReason(obj metav1.Object, lastSync map[types.UID]*metav1.Time,
+	now time.Time) string {
+	if !obj.GetDeletionTimestamp().IsZero() || now.Sub(obj.GetCreationTimestamp().Time) < gc.GracePeriod {
+		return ""
+	}
+	synced, found := lastSync[utils.CleanupOwner(obj)]
+	if !found {
+		return gcReasonOrphaned
+	}
+	if synced != nil && obj.GetCreationTimestamp().Time.Before(synced.Time) &&
+		now.Sub(synced.Time) >= gc.GracePeriod {
+		return gcReasonExpired
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/utils"
+)
+
+var _ = Describe("Orphan collector", func() {
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+
+	Context("identifies stale objects", func() {
+		gc := &OrphanCollector{GracePeriod: time.Hour}
+		now := time.Now()
+		owner := &volsyncv1alpha1.ReplicationSource{ObjectMeta: metav1.ObjectMeta{UID: "owner"}}
+		objCreatedAt := func(created time.Time, ownerUID types.UID) *corev1.PersistentVolumeClaim {
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(created),
+				},
+			}
+			utils.MarkForCleanup(&metav1.ObjectMeta{UID: ownerUID}, pvc)
+			return pvc
+		}
+		synced := func(t time.Time) map[types.UID]*metav1.Time {
+			last := metav1.NewTime(t)
+			return map[types.UID]*metav1.Time{owner.UID: &last}
+		}
+
+		It("keeps recently created objects", func() {
+			obj := objCreatedAt(now.Add(-time.Minute), "missing")
+			Expect(gc.staleReason(obj, synced(now), now)).To(BeEmpty())
+		})
+		It("collects objects whose owner doesn't exist", func() {
+			obj := objCreatedAt(now.Add(-2*time.Hour), "missing")
+			Expect(gc.staleReason(obj, synced(now), now)).To(Equal(gcReasonOrphaned))
+		})
+		It("keeps objects of an owner that hasn't synchronized", func() {
+			obj := objCreatedAt(now.Add(-2*time.Hour), owner.UID)
+			unsynced := map[types.UID]*metav1.Time{owner.UID: nil}
+			Expect(gc.staleReason(obj, unsynced, now)).To(BeEmpty())
+		})
+		It("keeps objects of the synchronization in progress", func() {
+			obj := objCreatedAt(now.Add(-2*time.Hour), owner.UID)
+			Expect(gc.staleReason(obj, synced(now.Add(-3*time.Hour)), now)).To(BeEmpty())
+		})
+		It("keeps objects of a recently completed synchronization", func() {
+			obj := objCreatedAt(now.Add(-2*time.Hour), owner.UID)
+			Expect(gc.staleReason(obj, synced(now.Add(-time.Minute)), now)).To(BeEmpty())
+		})
+		It("collects objects of a synchronization that completed long ago", func() {
+			obj := objCreatedAt(now.Add(-3*time.Hour), owner.UID)
+			Expect(gc.staleReason(obj, synced(now.Add(-2*time.Hour)), now)).To(Equal(gcReasonExpired))
+		})
+	})
+
+	Context("when collecting", func() {
+		var ctx = context.Background()
+		var namespace *corev1.Namespace
+		var rs *volsyncv1alpha1.ReplicationSource
+		var gc *OrphanCollector
+		newTemporaryPVC := func(name string, owner metav1.Object) *corev1.PersistentVolumeClaim {
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace.Name,
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			}
+			utils.MarkForCleanup(owner, pvc)
+			Expect(k8sClient.Create(ctx, pvc)).To(Succeed())
+			return pvc
+		}
+		isGone := func(obj client.Object) bool {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+			// envtest has no PVC protection controller, so the finalizer
+			// remains after the deletion
+			return kerrors.IsNotFound(err) || !obj.GetDeletionTimestamp().IsZero()
+		}
+
+		BeforeEach(func() {
+			namespace = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "volsync-test-",
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+			rs = &volsyncv1alpha1.ReplicationSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rs",
+					Namespace: namespace.Name,
+				},
+			}
+			Expect(k8sClient.Create(ctx, rs)).To(Succeed())
+			gc = &OrphanCollector{
+				Client: k8sClient,
+				Log:    logger,
+			}
+		})
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, namespace)).To(Succeed())
+		})
+
+		It("deletes objects whose owner is gone", func() {
+			gone := &metav1.ObjectMeta{UID: "no-such-owner"}
+			orphan := newTemporaryPVC("orphan", gone)
+			owned := newTemporaryPVC("owned", rs)
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(isGone(orphan)).To(BeTrue())
+			Expect(isGone(owned)).To(BeFalse())
+		})
+		It("only logs in dry-run mode", func() {
+			gc.DryRun = true
+			gone := &metav1.ObjectMeta{UID: "no-such-owner"}
+			orphan := newTemporaryPVC("orphan", gone)
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(isGone(orphan)).To(BeFalse())
+			stale := gcStaleObjects.WithLabelValues("PersistentVolumeClaim", gcReasonOrphaned)
+			found := testutil.ToFloat64(stale)
+			Expect(found).To(BeNumerically(">=", 1))
+			// The same objects aren't counted again by the next pass
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(testutil.ToFloat64(stale)).To(Equal(found))
+		})
+	})
+})
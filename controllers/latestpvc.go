@@ -0,0 +1,260 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+)
+
+const (
+	// Label that identifies the ReplicationDestination (by UID) that manages a
+	// latestPVC
+	latestPVCLabelKey = "volsync.backube/latest-pvc"
+	// How often to check whether an in-use latestPVC can be replaced
+	latestPVCRecheckInterval = time.Minute
+)
+
+// reconcileLatestPVC keeps the PVC named in spec.latestPVC provisioned from
+// status.latestImage. A PVC that was provisioned from an older image is
+// deleted (subject to the update strategy) and then recreated from the newer
+// one. The PVC is intentionally not owned by the ReplicationDestination so
+// that an application using it isn't affected if the ReplicationDestination
+// is removed. It returns true if the PVC should be checked again later.
+func reconcileLatestPVC(ctx context.Context, c client.Client,
+	inst *volsyncv1alpha1.ReplicationDestination, logger logr.Logger) (bool, error) {
+	spec := inst.Spec.LatestPVC
+	if spec == nil {
+		inst.Status.LatestPVC = nil
+		return false, nil
+	}
+	if opts := destinationVolumeOptions(inst); opts != nil && opts.CopyMethod == volsyncv1alpha1.CopyMethodNone {
+		// The image would be the destination volume itself, which is
+		// overwritten by each sync
+		return false, errors.New("latestPVC requires a copyMethod of Clone or Snapshot")
+	}
+	image := inst.Status.LatestImage
+	if image == nil {
+		// Nothing to provision from yet
+		return false, nil
+	}
+	if inst.Status.LatestPVC == nil {
+		inst.Status.LatestPVC = &volsyncv1alpha1.ReplicationDestinationLatestPVCStatus{}
+	}
+	l := logger.WithValues("latestPVC", spec.Name)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := c.Get(ctx, types.NamespacedName{Name: spec.Name, Namespace: inst.Namespace}, pvc)
+	if kerrors.IsNotFound(err) {
+		return createLatestPVC(ctx, c, inst, l)
+	}
+	if err != nil {
+		l.Error(err, "unable to get PVC")
+		return false, err
+	}
+	if pvc.Labels[latestPVCLabelKey] != string(inst.UID) {
+		return false, fmt.Errorf("latestPVC %s already exists and is not managed by this ReplicationDestination",
+			spec.Name)
+	}
+	if !pvc.DeletionTimestamp.IsZero() {
+		l.V(1).Info("PVC is being deleted-- need to wait")
+		return true, nil
+	}
+	if isSameImage(pvc.Spec.DataSource, image) {
+		inst.Status.LatestPVC.SourceImage = pvc.Spec.DataSource
+		inst.Status.LatestPVC.UpdatePending = false
+		return false, nil
+	}
+
+	// The PVC is out of date
+	if spec.UpdateStrategy != volsyncv1alpha1.LatestPVCUpdateRecreate {
+		inUse, err := isPVCInUse(ctx, c, pvc)
+		if err != nil {
+			l.Error(err, "unable to determine whether PVC is in use")
+			return false, err
+		}
+		if inUse {
+			l.V(1).Info("PVC is in use-- waiting to replace it")
+			inst.Status.LatestPVC.UpdatePending = true
+			return true, nil
+		}
+	}
+	if err := c.Delete(ctx, pvc); err != nil && !kerrors.IsNotFound(err) {
+		l.Error(err, "unable to delete outdated PVC")
+		return false, err
+	}
+	l.Info("deleted outdated PVC", "image", pvc.Spec.DataSource)
+	// It's recreated once the deletion completes, which may be right away
+	err = c.Get(ctx, client.ObjectKeyFromObject(pvc), pvc)
+	if kerrors.IsNotFound(err) {
+		return createLatestPVC(ctx, c, inst, l)
+	}
+	return true, client.IgnoreNotFound(err)
+}
+
+func createLatestPVC(ctx context.Context, c client.Client,
+	inst *volsyncv1alpha1.ReplicationDestination, logger logr.Logger) (bool, error) {
+	spec := inst.Spec.LatestPVC
+	image := inst.Status.LatestImage
+
+	// Default the parameters from the image and the volume it came from
+	var origin *corev1.PersistentVolumeClaim
+	var capacity *resource.Quantity
+	switch image.Kind {
+	case "PersistentVolumeClaim":
+		origin = &corev1.PersistentVolumeClaim{}
+		if err := c.Get(ctx, types.NamespacedName{Name: image.Name, Namespace: inst.Namespace}, origin); err != nil {
+			logger.Error(err, "unable to get image PVC")
+			return false, err
+		}
+		capacity = origin.Spec.Resources.Requests.Storage()
+	case "VolumeSnapshot":
+		snap := &snapv1.VolumeSnapshot{}
+		if err := c.Get(ctx, types.NamespacedName{Name: image.Name, Namespace: inst.Namespace}, snap); err != nil {
+			logger.Error(err, "unable to get image VolumeSnapshot")
+			return false, err
+		}
+		if snap.Status != nil && snap.Status.RestoreSize != nil {
+			capacity = snap.Status.RestoreSize
+		}
+		if snap.Spec.Source.PersistentVolumeClaimName != nil {
+			origin = &corev1.PersistentVolumeClaim{}
+			err := c.Get(ctx, types.NamespacedName{
+				Name:      *snap.Spec.Source.PersistentVolumeClaimName,
+				Namespace: inst.Namespace,
+			}, origin)
+			if kerrors.IsNotFound(err) {
+				origin = nil
+			} else if err != nil {
+				logger.Error(err, "unable to get snapshotted PVC")
+				return false, err
+			}
+		}
+		if capacity == nil && origin != nil {
+			capacity = origin.Spec.Resources.Requests.Storage()
+		}
+	default:
+		return false, fmt.Errorf("latestPVC can not be provisioned from a %s", image.Kind)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      spec.Name,
+			Namespace: inst.Namespace,
+			Labels:    map[string]string{latestPVCLabelKey: string(inst.UID)},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      spec.AccessModes,
+			StorageClassName: spec.StorageClassName,
+			DataSource:       image,
+		},
+	}
+	if origin != nil {
+		if len(pvc.Spec.AccessModes) == 0 {
+			pvc.Spec.AccessModes = origin.Spec.AccessModes
+		}
+		if pvc.Spec.StorageClassName == nil {
+			pvc.Spec.StorageClassName = origin.Spec.StorageClassName
+		}
+		pvc.Spec.VolumeMode = origin.Spec.VolumeMode
+	}
+	if spec.Capacity != nil {
+		capacity = spec.Capacity
+	}
+	if len(pvc.Spec.AccessModes) == 0 {
+		return false, errors.New("latestPVC accessModes must be provided")
+	}
+	if capacity == nil || capacity.IsZero() {
+		return false, errors.New("latestPVC capacity must be provided")
+	}
+	pvc.Spec.Resources.Requests = corev1.ResourceList{
+		corev1.ResourceStorage: *capacity,
+	}
+
+	if err := c.Create(ctx, pvc); err != nil {
+		logger.Error(err, "unable to create PVC")
+		return false, err
+	}
+	logger.Info("provisioned PVC from latest image", "image", image)
+	inst.Status.LatestPVC.SourceImage = image
+	inst.Status.LatestPVC.UpdatePending = false
+	return false, nil
+}
+
+// destinationVolumeOptions returns the volume options of the replication
+// method in use, or nil if there are none
+func destinationVolumeOptions(
+	inst *volsyncv1alpha1.ReplicationDestination) *volsyncv1alpha1.ReplicationDestinationVolumeOptions {
+	switch {
+	case inst.Spec.Rsync != nil:
+		return &inst.Spec.Rsync.ReplicationDestinationVolumeOptions
+	case inst.Spec.Rclone != nil:
+		return &inst.Spec.Rclone.ReplicationDestinationVolumeOptions
+	case inst.Spec.Restic != nil:
+		return &inst.Spec.Restic.ReplicationDestinationVolumeOptions
+	case inst.Spec.RsyncTLS != nil:
+		return &inst.Spec.RsyncTLS.ReplicationDestinationVolumeOptions
+	default:
+		return nil
+	}
+}
+
+func isSameImage(a, b *corev1.TypedLocalObjectReference) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	groupOf := func(ref *corev1.TypedLocalObjectReference) string {
+		if ref.APIGroup == nil {
+			return ""
+		}
+		return *ref.APIGroup
+	}
+	return a.Kind == b.Kind && a.Name == b.Name && groupOf(a) == groupOf(b)
+}
+
+// isPVCInUse returns true if a running (or pending) Pod mounts the PVC
+func isPVCInUse(ctx context.Context, c client.Client, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	podList := &corev1.PodList{}
+	if err := c.List(ctx, podList, client.InNamespace(pvc.Namespace)); err != nil {
+		return false, err
+	}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == pvc.Name {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"context"
+
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+)
+
+var _ = Describe("Destination latestPVC", func() {
+	var ctx = context.Background()
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+	var namespace *corev1.Namespace
+	var rd *volsyncv1alpha1.ReplicationDestination
+	var destPVC *corev1.PersistentVolumeClaim
+	snapGroup := snapv1.SchemeGroupVersion.Group
+
+	// Records a new snapshot of the destination PVC as the latestImage
+	newImage := func(name string) {
+		snap := &snapv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace.Name,
+			},
+			Spec: snapv1.VolumeSnapshotSpec{
+				Source: snapv1.VolumeSnapshotSource{
+					PersistentVolumeClaimName: &destPVC.Name,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, snap)).To(Succeed())
+		rd.Status.LatestImage = &corev1.TypedLocalObjectReference{
+			APIGroup: &snapGroup,
+			Kind:     "VolumeSnapshot",
+			Name:     name,
+		}
+	}
+	// Starts a Pod that uses the latestPVC
+	usePVC := func() *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app",
+				Namespace: namespace.Name,
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "c", Image: "app"}},
+				Volumes: []corev1.Volume{{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: "app-data",
+						},
+					},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		return pod
+	}
+	getLatestPVC := func() *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "app-data", Namespace: namespace.Name},
+			pvc)).To(Succeed())
+		return pvc
+	}
+
+	BeforeEach(func() {
+		namespace = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "volsync-test-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+		sc := "fast"
+		destPVC = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "volsync-dest-rd",
+				Namespace: namespace.Name,
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("3Gi"),
+					},
+				},
+				StorageClassName: &sc,
+			},
+		}
+		Expect(k8sClient.Create(ctx, destPVC)).To(Succeed())
+		rd = &volsyncv1alpha1.ReplicationDestination{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rd",
+				Namespace: namespace.Name,
+			},
+			Spec: volsyncv1alpha1.ReplicationDestinationSpec{
+				LatestPVC: &volsyncv1alpha1.ReplicationDestinationLatestPVCSpec{
+					Name: "app-data",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, rd)).To(Succeed())
+		rd.Status = &volsyncv1alpha1.ReplicationDestinationStatus{}
+	})
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, namespace)).To(Succeed())
+	})
+
+	It("waits for an image", func() {
+		recheck, err := reconcileLatestPVC(ctx, k8sClient, rd, logger)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recheck).To(BeFalse())
+		Expect(rd.Status.LatestPVC).To(BeNil())
+	})
+
+	It("is rejected with a copyMethod of None", func() {
+		rd.Spec.Rsync = &volsyncv1alpha1.ReplicationDestinationRsyncSpec{
+			ReplicationDestinationVolumeOptions: volsyncv1alpha1.ReplicationDestinationVolumeOptions{
+				CopyMethod: volsyncv1alpha1.CopyMethodNone,
+			},
+		}
+		newImage("snap1")
+		_, err := reconcileLatestPVC(ctx, k8sClient, rd, logger)
+		Expect(err).To(MatchError(ContainSubstring("copyMethod")))
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = k8sClient.Get(ctx, types.NamespacedName{Name: "app-data", Namespace: namespace.Name}, pvc)
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("provisions the PVC from the latest image", func() {
+		newImage("snap1")
+		Expect(reconcileLatestPVC(ctx, k8sClient, rd, logger)).To(BeFalse())
+		pvc := getLatestPVC()
+		Expect(pvc.Spec.DataSource.Name).To(Equal("snap1"))
+		Expect(pvc.Spec.AccessModes).To(Equal(destPVC.Spec.AccessModes))
+		Expect(*pvc.Spec.StorageClassName).To(Equal(*destPVC.Spec.StorageClassName))
+		Expect(*pvc.Spec.Resources.Requests.Storage()).To(Equal(resource.MustParse("3Gi")))
+		Expect(pvc.OwnerReferences).To(BeEmpty())
+		Expect(rd.Status.LatestPVC.SourceImage.Name).To(Equal("snap1"))
+	})
+
+	It("uses the configured parameters", func() {
+		capacity := resource.MustParse("5Gi")
+		rd.Spec.LatestPVC.Capacity = &capacity
+		rd.Spec.LatestPVC.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+		newImage("snap1")
+		Expect(reconcileLatestPVC(ctx, k8sClient, rd, logger)).To(BeFalse())
+		pvc := getLatestPVC()
+		Expect(pvc.Spec.AccessModes).To(ConsistOf(corev1.ReadWriteOnce))
+		Expect(*pvc.Spec.Resources.Requests.Storage()).To(Equal(capacity))
+	})
+
+	It("waits until the PVC is unused to replace it", func() {
+		newImage("snap1")
+		Expect(reconcileLatestPVC(ctx, k8sClient, rd, logger)).To(BeFalse())
+
+		pod := usePVC()
+
+		newImage("snap2")
+		Expect(reconcileLatestPVC(ctx, k8sClient, rd, logger)).To(BeTrue())
+		Expect(rd.Status.LatestPVC.UpdatePending).To(BeTrue())
+		Expect(getLatestPVC().Spec.DataSource.Name).To(Equal("snap1"))
+
+		Expect(k8sClient.Delete(ctx, pod, client.GracePeriodSeconds(0))).To(Succeed())
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)
+			return kerrors.IsNotFound(err)
+		}, maxWait, interval).Should(BeTrue())
+		Eventually(func() string {
+			_, _ = reconcileLatestPVC(ctx, k8sClient, rd, logger)
+			pvc := &corev1.PersistentVolumeClaim{}
+			_ = k8sClient.Get(ctx, types.NamespacedName{Name: "app-data", Namespace: namespace.Name}, pvc)
+			if pvc.Spec.DataSource == nil {
+				return ""
+			}
+			return pvc.Spec.DataSource.Name
+		}, maxWait, interval).Should(Equal("snap2"))
+		Expect(rd.Status.LatestPVC.UpdatePending).To(BeFalse())
+	})
+
+	It("replaces an in-use PVC with the Recreate strategy", func() {
+		rd.Spec.LatestPVC.UpdateStrategy = volsyncv1alpha1.LatestPVCUpdateRecreate
+		newImage("snap1")
+		Expect(reconcileLatestPVC(ctx, k8sClient, rd, logger)).To(BeFalse())
+		usePVC()
+		newImage("snap2")
+		Eventually(func() string {
+			_, _ = reconcileLatestPVC(ctx, k8sClient, rd, logger)
+			pvc := &corev1.PersistentVolumeClaim{}
+			_ = k8sClient.Get(ctx, types.NamespacedName{Name: "app-data", Namespace: namespace.Name}, pvc)
+			if pvc.Spec.DataSource == nil {
+				return ""
+			}
+			return pvc.Spec.DataSource.Name
+		}, maxWait, interval).Should(Equal("snap2"))
+	})
+
+	It("doesn't take over a PVC that it didn't create", func() {
+		existing := destPVC.DeepCopy()
+		existing.ObjectMeta = metav1.ObjectMeta{
+			Name:      "app-data",
+			Namespace: namespace.Name,
+		}
+		Expect(k8sClient.Create(ctx, existing)).To(Succeed())
+		newImage("snap1")
+		_, err := reconcileLatestPVC(ctx, k8sClient, rd, logger)
+		Expect(err).To(MatchError(ContainSubstring("not managed by this ReplicationDestination")))
+	})
+})
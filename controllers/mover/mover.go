@@ -19,6 +19,7 @@ package mover
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -40,6 +41,19 @@ type Mover interface {
 	Cleanup(ctx context.Context) (Result, error)
 }
 
+// Finalizer is implemented by the Movers that keep replicated data outside of
+// the cluster
+type Finalizer interface {
+	// Finalize begins or continues removing the replicated data when the owner
+	// is deleted with a deletionPolicy of Delete. It will be called until the
+	// Result indicates that it is complete. Must be idempotent.
+	Finalize(ctx context.Context) (Result, error)
+}
+
+// ErrDeadlineExceeded is returned (wrapped) by a Mover when a synchronization
+// attempt has been abandoned because it ran longer than permitted.
+var ErrDeadlineExceeded = errors.New("synchronization deadline exceeded")
+
 // Result indicates the outcome of a synchronization attempt
 type Result struct {
 	// Completed is set to true if the synchronization has completed. RetryAfter
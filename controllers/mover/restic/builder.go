@@ -61,11 +61,15 @@ func (rb *Builder) FromSource(client client.Client, logger logr.Logger,
 		volumehandler.WithClient(client),
 		volumehandler.WithOwner(source),
 		volumehandler.FromSource(&source.Spec.Restic.ReplicationSourceVolumeOptions),
+		volumehandler.SelectedCopyMethod(&source.Status.SelectedCopyMethod),
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	// Sources create the repository on their first backup unless told otherwise
+	initPolicy := initializePolicy(source.Spec.Restic.Initialize, volsyncv1alpha1.ResticInitializeAuto)
+
 	return &Mover{
 		client:                client,
 		logger:                logger.WithValues("method", "Restic"),
@@ -77,10 +81,13 @@ func (rb *Builder) FromSource(client client.Client, logger logr.Logger,
 		repositoryName:        source.Spec.Restic.Repository,
 		isSource:              true,
 		paused:                source.Spec.Paused,
+		moverSA:               source.Spec.MoverServiceAccount,
 		mainPVCName:           &source.Spec.SourcePVC,
 		pruneInterval:         source.Spec.Restic.PruneIntervalDays,
 		retainPolicy:          source.Spec.Restic.Retain,
 		sourceStatus:          source.Status.Restic,
+		initializePolicy:      initPolicy,
+		jobOptions:            source.Spec.Restic.ResticJobOptions,
 	}, nil
 }
 
@@ -91,6 +98,11 @@ func (rb *Builder) FromDestination(client client.Client, logger logr.Logger,
 		return nil, nil
 	}
 
+	// Create ReplicationDestinationResticStatus to write restic status
+	if destination.Status.Restic == nil {
+		destination.Status.Restic = &volsyncv1alpha1.ReplicationDestinationResticStatus{}
+	}
+
 	vh, err := volumehandler.NewVolumeHandler(
 		volumehandler.WithClient(client),
 		volumehandler.WithOwner(destination),
@@ -100,6 +112,10 @@ func (rb *Builder) FromDestination(client client.Client, logger logr.Logger,
 		return nil, err
 	}
 
+	// Destinations restore from an existing repository unless told otherwise
+	initPolicy := initializePolicy(destination.Spec.Restic.Initialize,
+		volsyncv1alpha1.ResticInitializeRequireExisting)
+
 	return &Mover{
 		client:                client,
 		logger:                logger.WithValues("method", "Restic"),
@@ -111,6 +127,29 @@ func (rb *Builder) FromDestination(client client.Client, logger logr.Logger,
 		repositoryName:        destination.Spec.Restic.Repository,
 		isSource:              false,
 		paused:                destination.Spec.Paused,
+		moverSA:               destination.Spec.MoverServiceAccount,
 		mainPVCName:           destination.Spec.Restic.DestinationPVC,
+		verifyOnly:            isVerifyMode(destination.Spec.Restic.Mode),
+		destStatus:            destination.Status.Restic,
+		initializePolicy:      initPolicy,
+		restoreSnapshot:       destination.Spec.Restic.Snapshot,
+		targetCleanup:         destination.Spec.Restic.TargetCleanup,
+		restoreInclude:        destination.Spec.Restic.Include,
+		restoreExclude:        destination.Spec.Restic.Exclude,
+		targetSubdirectory:    destination.Spec.Restic.TargetSubdirectory,
+		verifyRestore:         destination.Spec.Restic.Verify,
+		jobOptions:            destination.Spec.Restic.ResticJobOptions,
 	}, nil
 }
+
+func isVerifyMode(mode *volsyncv1alpha1.ResticDestinationModeType) bool {
+	return mode != nil && *mode == volsyncv1alpha1.ResticDestinationModeVerify
+}
+
+func initializePolicy(policy *volsyncv1alpha1.ResticInitializePolicyType,
+	def volsyncv1alpha1.ResticInitializePolicyType) volsyncv1alpha1.ResticInitializePolicyType {
+	if policy == nil {
+		return def
+	}
+	return *policy
+}
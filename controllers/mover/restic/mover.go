@@ -19,14 +19,18 @@ package restic
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -44,6 +48,10 @@ const (
 	mountPath            = "/data"
 	dataVolumeName       = "data"
 	resticCache          = "cache"
+	// Maximum number of repository snapshots to publish in the status
+	maxSnapshotsInStatus = 10
+	// Number of times a failed mover attempt is retried if not specified
+	defaultBackoffLimit = int32(8)
 )
 
 // Mover is the reconciliation logic for the Restic-based data mover.
@@ -58,14 +66,27 @@ type Mover struct {
 	repositoryName        string
 	isSource              bool
 	paused                bool
+	moverSA               *string
+	jobOptions            volsyncv1alpha1.ResticJobOptions
+	initializePolicy      volsyncv1alpha1.ResticInitializePolicyType
 	mainPVCName           *string
 	// Source-only fields
 	pruneInterval *int32
 	retainPolicy  *volsyncv1alpha1.ResticRetainPolicy
 	sourceStatus  *volsyncv1alpha1.ReplicationSourceResticStatus
+	// Destination-only fields
+	verifyOnly         bool
+	destStatus         *volsyncv1alpha1.ReplicationDestinationResticStatus
+	restoreSnapshot    *string
+	targetCleanup      *volsyncv1alpha1.ResticTargetCleanupType
+	restoreInclude     []string
+	restoreExclude     []string
+	targetSubdirectory *string
+	verifyRestore      bool
 }
 
 var _ mover.Mover = &Mover{}
+var _ mover.Finalizer = &Mover{}
 
 // All object types that are temporary/per-iteration should be listed here. The
 // individual objects to be cleaned up must also be marked.
@@ -79,10 +100,18 @@ func (m *Mover) Name() string { return "restic" }
 
 func (m *Mover) Synchronize(ctx context.Context) (mover.Result, error) {
 	var err error
+	if !m.isSource {
+		if err = m.validateRestoreOptions(); err != nil {
+			return mover.InProgress(), err
+		}
+	}
+
 	// Allocate temporary data PVC
 	var dataPVC *v1.PersistentVolumeClaim
 	if m.isSource {
 		dataPVC, err = m.ensureSourcePVC(ctx)
+	} else if m.verifyOnly {
+		dataPVC, err = m.ensureVerifyPVC(ctx)
 	} else {
 		dataPVC, err = m.ensureDestinationPVC(ctx)
 	}
@@ -114,6 +143,12 @@ func (m *Mover) Synchronize(ctx context.Context) (mover.Result, error) {
 		return mover.InProgress(), err
 	}
 
+	// A test restore doesn't produce an image; the scratch volume is
+	// removed during cleanup
+	if m.verifyOnly {
+		return mover.Complete(), nil
+	}
+
 	// On the destination, preserve the image and return it
 	if !m.isSource {
 		image, err := m.vh.EnsureImage(ctx, m.logger, dataPVC)
@@ -135,6 +170,127 @@ func (m *Mover) Cleanup(ctx context.Context) (mover.Result, error) {
 	return mover.Complete(), nil
 }
 
+// Finalize removes the snapshots that the source has made (those with its tag)
+// from the repository.
+// Destinations only read from the repository, so they have nothing to remove.
+func (m *Mover) Finalize(ctx context.Context) (mover.Result, error) {
+	if !m.isSource {
+		return mover.Complete(), nil
+	}
+	// Stop any backup that's in progress so it doesn't race with the removal
+	err := utils.CleanupObjects(ctx, m.client, m.logger, m.owner, cleanupTypes)
+	if err != nil {
+		return mover.InProgress(), err
+	}
+
+	repo, err := m.validateRepository(ctx)
+	if kerrors.IsNotFound(err) {
+		// Without the repository credentials, there's nothing we can remove
+		m.logger.Info("repository Secret not found-- unable to remove snapshots")
+		return mover.Complete(), nil
+	}
+	if repo == nil || err != nil {
+		return mover.InProgress(), err
+	}
+	sa, err := m.ensureSA(ctx)
+	if sa == nil || err != nil {
+		return m.namespaceTerminating(err)
+	}
+	job, err := m.ensureDeleteJob(ctx, sa, repo)
+	if job == nil || err != nil {
+		return m.namespaceTerminating(err)
+	}
+	return mover.Complete(), nil
+}
+
+// sourceTag is the restic tag that identifies the snapshots made by this
+// source, so that only those are removed when it is deleted
+func (m *Mover) sourceTag() string {
+	return "volsync-source-" + string(m.owner.GetUID())
+}
+
+// namespaceTerminating completes the removal if the error is because the
+// namespace is being deleted, since the Job can no longer be created
+func (m *Mover) namespaceTerminating(err error) (mover.Result, error) {
+	if kerrors.HasStatusCause(err, v1.NamespaceTerminatingCause) {
+		m.logger.Info("namespace is terminating-- unable to remove snapshots")
+		return mover.Complete(), nil
+	}
+	return mover.InProgress(), err
+}
+
+// ensureDeleteJob runs the Job that removes the source's snapshots from the
+// repository. It returns the Job once it has succeeded.
+func (m *Mover) ensureDeleteJob(ctx context.Context, sa *v1.ServiceAccount,
+	repo *v1.Secret) (*batchv1.Job, error) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "volsync-delete-" + m.owner.GetName(),
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	logger := m.logger.WithValues("job", utils.NameFor(job))
+	_, err := ctrlutil.CreateOrUpdate(ctx, m.client, job, func() error {
+		if err := ctrl.SetControllerReference(m.owner, job, m.client.Scheme()); err != nil {
+			logger.Error(err, "unable to set controller reference")
+			return err
+		}
+		job.Spec.Template.ObjectMeta.Name = job.Name
+		backoffLimit := defaultBackoffLimit
+		if m.jobOptions.BackoffLimit != nil {
+			backoffLimit = *m.jobOptions.BackoffLimit
+		}
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template.Spec.ActiveDeadlineSeconds = m.jobOptions.AttemptTimeoutSeconds
+		runAsUser := int64(0)
+		env := []v1.EnvVar{
+			// The data directory isn't used, but it must be defined
+			{Name: "DATA_DIR", Value: mountPath},
+			{Name: "RESTIC_CACHE_DIR", Value: resticCacheMountPath},
+			{Name: "SOURCE_TAG", Value: m.sourceTag()},
+		}
+		job.Spec.Template.Spec.Containers = []v1.Container{{
+			Name:    "restic",
+			Env:     append(env, repositoryEnv(repo.Name)...),
+			Command: []string{"/entry.sh"},
+			Args:    []string{"delete"},
+			Image:   resticContainerImage,
+			SecurityContext: &corev1.SecurityContext{
+				RunAsUser: &runAsUser,
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: resticCache, MountPath: resticCacheMountPath},
+			},
+		}}
+		job.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyNever
+		job.Spec.Template.Spec.ServiceAccountName = sa.Name
+		job.Spec.Template.Spec.Volumes = []v1.Volume{
+			{Name: resticCache, VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			}},
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "reconcile failed")
+		return nil, err
+	}
+	// If Job had failed, delete it so it can be recreated
+	if job.Status.Failed > 0 && job.Status.Failed >= *job.Spec.BackoffLimit {
+		logger.Info("deleting job -- backoff limit reached")
+		err = m.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err == nil {
+			err = fmt.Errorf("job %v was unable to remove the snapshots from the repository", utils.NameFor(job))
+		}
+		return nil, err
+	}
+	if job.Status.Succeeded == 0 {
+		return nil, nil
+	}
+	logger.Info("snapshots removed from repository")
+	return job, nil
+}
+
 func (m *Mover) ensureCache(ctx context.Context,
 	dataPVC *v1.PersistentVolumeClaim) (*v1.PersistentVolumeClaim, error) {
 	// Create a separate vh for the Restic cache volume that's based on the main
@@ -151,6 +307,10 @@ func (m *Mover) ensureCache(ctx context.Context,
 	}
 	cacheConfig = append(cacheConfig, volumehandler.Capacity(&cacheCapacity))
 
+	// The cache is a filesystem, even when the data volume is a block device
+	filesystem := v1.PersistentVolumeFilesystem
+	cacheConfig = append(cacheConfig, volumehandler.VolumeMode(&filesystem))
+
 	// AccessModes are generated in the following priority:
 	// 1. Directly specified cache accessMode
 	// 2. Directly specified volume accessMode
@@ -173,7 +333,7 @@ func (m *Mover) ensureCache(ctx context.Context,
 	// Allocate cache volume
 	cacheName := "volsync-" + m.owner.GetName() + "-cache"
 	m.logger.Info("allocating cache volume", "PVC", cacheName)
-	return cacheVh.EnsureNewPVC(ctx, m.logger, cacheName)
+	return cacheVh.EnsureNewPVC(ctx, m.logger, cacheName, false)
 }
 
 func (m *Mover) ensureSourcePVC(ctx context.Context) (*v1.PersistentVolumeClaim, error) {
@@ -194,7 +354,7 @@ func (m *Mover) ensureDestinationPVC(ctx context.Context) (*v1.PersistentVolumeC
 	if m.mainPVCName == nil {
 		// Need to allocate the incoming data volume
 		dataPVCName := "volsync-" + m.owner.GetName() + "-dest"
-		return m.vh.EnsureNewPVC(ctx, m.logger, dataPVCName)
+		return m.vh.EnsureNewPVC(ctx, m.logger, dataPVCName, false)
 	}
 
 	// use provided PVC
@@ -208,6 +368,13 @@ func (m *Mover) ensureDestinationPVC(ctx context.Context) (*v1.PersistentVolumeC
 	return pvc, err
 }
 
+func (m *Mover) ensureVerifyPVC(ctx context.Context) (*v1.PersistentVolumeClaim, error) {
+	// The test restore goes into a scratch volume that is removed once the
+	// verification is complete
+	verifyPVCName := "volsync-" + m.owner.GetName() + "-verify"
+	return m.vh.EnsureNewPVC(ctx, m.logger, verifyPVCName, true)
+}
+
 func (m *Mover) ensureSA(ctx context.Context) (*v1.ServiceAccount, error) {
 	dir := "src"
 	if !m.isSource {
@@ -219,7 +386,7 @@ func (m *Mover) ensureSA(ctx context.Context) (*v1.ServiceAccount, error) {
 			Namespace: m.owner.GetNamespace(),
 		},
 	}
-	saDesc := utils.NewSAHandler(ctx, m.client, m.owner, sa)
+	saDesc := utils.NewSAHandler(ctx, m.client, m.owner, sa, m.moverSA)
 	cont, err := saDesc.Reconcile(m.logger)
 	if cont {
 		return sa, err
@@ -265,8 +432,13 @@ func (m *Mover) ensureJob(ctx context.Context, cachePVC *v1.PersistentVolumeClai
 		}
 		utils.MarkForCleanup(m.owner, job)
 		job.Spec.Template.ObjectMeta.Name = job.Name
-		backoffLimit := int32(8)
+		backoffLimit := defaultBackoffLimit
+		if m.jobOptions.BackoffLimit != nil {
+			backoffLimit = *m.jobOptions.BackoffLimit
+		}
 		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.ActiveDeadlineSeconds = m.jobOptions.SyncTimeoutSeconds
+		job.Spec.Template.Spec.ActiveDeadlineSeconds = m.jobOptions.AttemptTimeoutSeconds
 		parallelism := int32(1)
 		if m.paused {
 			parallelism = int32(0)
@@ -281,57 +453,32 @@ func (m *Mover) ensureJob(ctx context.Context, cachePVC *v1.PersistentVolumeClai
 			if m.shouldPrune(time.Now()) {
 				actions = append(actions, "prune")
 			}
+		} else if m.verifyOnly {
+			actions = []string{"verify"}
 		} else {
 			actions = []string{"restore"}
 		}
+		if m.initializePolicy != volsyncv1alpha1.ResticInitializeNever {
+			actions = append([]string{"init"}, actions...)
+		}
 		logger.Info("job actions", "actions", actions)
 
+		env := []v1.EnvVar{
+			{Name: "FORGET_OPTIONS", Value: forgetOptions},
+			{Name: "DATA_DIR", Value: mountPath},
+			{Name: "RESTIC_CACHE_DIR", Value: resticCacheMountPath},
+			{Name: "SNAPSHOT_LIST_MAX", Value: fmt.Sprint(maxSnapshotsInStatus)},
+			{Name: "INITIALIZE_POLICY", Value: string(m.initializePolicy)},
+		}
+		if m.isSource {
+			env = append(env, v1.EnvVar{Name: "SOURCE_TAG", Value: m.sourceTag()})
+		} else {
+			env = append(env, m.restoreEnv()...)
+		}
+
 		job.Spec.Template.Spec.Containers = []v1.Container{{
-			Name: "restic",
-			Env: []v1.EnvVar{
-				{Name: "FORGET_OPTIONS", Value: forgetOptions},
-				{Name: "DATA_DIR", Value: mountPath},
-				{Name: "RESTIC_CACHE_DIR", Value: resticCacheMountPath},
-				// We populate environment variables from the restic repo
-				// Secret. They are taken 1-for-1 from the Secret into env vars.
-				// The allowed variables are defined by restic.
-				// https://restic.readthedocs.io/en/stable/040_backup.html#environment-variables
-				// Mandatory variables are needed to define the repository
-				// location and its password.
-				utils.EnvFromSecret(repo.Name, "RESTIC_REPOSITORY", false),
-				utils.EnvFromSecret(repo.Name, "RESTIC_PASSWORD", false),
-				// Optional variables based on what backend is used for restic
-				utils.EnvFromSecret(repo.Name, "AWS_ACCESS_KEY_ID", true),
-				utils.EnvFromSecret(repo.Name, "AWS_SECRET_ACCESS_KEY", true),
-				utils.EnvFromSecret(repo.Name, "AWS_DEFAULT_REGION", true),
-				utils.EnvFromSecret(repo.Name, "ST_AUTH", true),
-				utils.EnvFromSecret(repo.Name, "ST_USER", true),
-				utils.EnvFromSecret(repo.Name, "ST_KEY", true),
-				utils.EnvFromSecret(repo.Name, "OS_AUTH_URL", true),
-				utils.EnvFromSecret(repo.Name, "OS_REGION_NAME", true),
-				utils.EnvFromSecret(repo.Name, "OS_USERNAME", true),
-				utils.EnvFromSecret(repo.Name, "OS_USER_ID", true),
-				utils.EnvFromSecret(repo.Name, "OS_PASSWORD", true),
-				utils.EnvFromSecret(repo.Name, "OS_TENANT_ID", true),
-				utils.EnvFromSecret(repo.Name, "OS_TENANT_NAME", true),
-				utils.EnvFromSecret(repo.Name, "OS_USER_DOMAIN_NAME", true),
-				utils.EnvFromSecret(repo.Name, "OS_USER_DOMAIN_ID", true),
-				utils.EnvFromSecret(repo.Name, "OS_PROJECT_NAME", true),
-				utils.EnvFromSecret(repo.Name, "OS_PROJECT_DOMAIN_NAME", true),
-				utils.EnvFromSecret(repo.Name, "OS_PROJECT_DOMAIN_ID", true),
-				utils.EnvFromSecret(repo.Name, "OS_TRUST_ID", true),
-				utils.EnvFromSecret(repo.Name, "OS_APPLICATION_CREDENTIAL_ID", true),
-				utils.EnvFromSecret(repo.Name, "OS_APPLICATION_CREDENTIAL_NAME", true),
-				utils.EnvFromSecret(repo.Name, "OS_APPLICATION_CREDENTIAL_SECRET", true),
-				utils.EnvFromSecret(repo.Name, "OS_STORAGE_URL", true),
-				utils.EnvFromSecret(repo.Name, "OS_AUTH_TOKEN", true),
-				utils.EnvFromSecret(repo.Name, "B2_ACCOUNT_ID", true),
-				utils.EnvFromSecret(repo.Name, "B2_ACCOUNT_KEY", true),
-				utils.EnvFromSecret(repo.Name, "AZURE_ACCOUNT_NAME", true),
-				utils.EnvFromSecret(repo.Name, "AZURE_ACCOUNT_KEY", true),
-				utils.EnvFromSecret(repo.Name, "GOOGLE_PROJECT_ID", true),
-				utils.EnvFromSecret(repo.Name, "GOOGLE_APPLICATION_CREDENTIALS", true),
-			},
+			Name:    "restic",
+			Env:     append(env, repositoryEnv(repo.Name)...),
 			Command: []string{"/entry.sh"},
 			Args:    actions,
 			Image:   resticContainerImage,
@@ -339,10 +486,10 @@ func (m *Mover) ensureJob(ctx context.Context, cachePVC *v1.PersistentVolumeClai
 				RunAsUser: &runAsUser,
 			},
 			VolumeMounts: []corev1.VolumeMount{
-				{Name: dataVolumeName, MountPath: mountPath},
 				{Name: resticCache, MountPath: resticCacheMountPath},
 			},
 		}}
+		utils.AttachDataVolume(&job.Spec.Template.Spec.Containers[0], dataVolumeName, dataPVC, mountPath)
 		job.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyNever
 		job.Spec.Template.Spec.ServiceAccountName = sa.Name
 		job.Spec.Template.Spec.Volumes = []v1.Volume{
@@ -359,8 +506,30 @@ func (m *Mover) ensureJob(ctx context.Context, cachePVC *v1.PersistentVolumeClai
 		}
 		return nil
 	})
+	// A failed verification is a result to report rather than something to
+	// retry. The Job is removed during cleanup.
+	if m.verifyOnly && (utils.JobDeadlineExceeded(job) ||
+		(job.Status.Failed > 0 && job.Status.Failed >= *job.Spec.BackoffLimit)) {
+		logger.Info("verification failed")
+		m.recordVerification(job, false)
+		return job, nil
+	}
+	// If the Job ran past its deadline, delete it so it can be recreated, and
+	// report why this attempt was abandoned
+	if utils.JobDeadlineExceeded(job) {
+		logger.Info("deleting job -- deadline exceeded")
+		if err = m.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err == nil {
+			err = fmt.Errorf("%w: job %v did not complete within %vs", mover.ErrDeadlineExceeded,
+				utils.NameFor(job), *job.Spec.ActiveDeadlineSeconds)
+		}
+		return nil, err
+	}
 	// If Job had failed, delete it so it can be recreated
-	if job.Status.Failed >= *job.Spec.BackoffLimit {
+	if job.Status.Failed > 0 && job.Status.Failed >= *job.Spec.BackoffLimit {
+		// Record if the failure was due to a missing repository
+		if err := m.processMoverResult(ctx, job, v1.PodFailed); err != nil {
+			logger.Error(err, "unable to retrieve mover result")
+		}
 		logger.Info("deleting job -- backoff limit reached")
 		err = m.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
 		return nil, err
@@ -380,10 +549,215 @@ func (m *Mover) ensureJob(ctx context.Context, cachePVC *v1.PersistentVolumeClai
 		m.sourceStatus.LastPruned = &now
 		logger.Info("prune completed", ".Status.Restic.LastPruned", m.sourceStatus.LastPruned)
 	}
+	if m.verifyOnly {
+		logger.Info("verification passed")
+		m.recordVerification(job, true)
+	}
+	// The mover result is informational, so failing to retrieve it shouldn't
+	// hold up the synchronization.
+	if err := m.processMoverResult(ctx, job, v1.PodSucceeded); err != nil {
+		logger.Error(err, "unable to retrieve mover result")
+	}
 	// We only continue reconciling if the restic job has completed
 	return job, nil
 }
 
+// repositoryEnv returns the environment variables that are populated from the
+// restic repository Secret
+func repositoryEnv(secretName string) []v1.EnvVar {
+	return []v1.EnvVar{
+		// We populate environment variables from the restic repo
+		// Secret. They are taken 1-for-1 from the Secret into env vars.
+		// The allowed variables are defined by restic.
+		// https://restic.readthedocs.io/en/stable/040_backup.html#environment-variables
+		// Mandatory variables are needed to define the repository
+		// location and its password.
+		utils.EnvFromSecret(secretName, "RESTIC_REPOSITORY", false),
+		utils.EnvFromSecret(secretName, "RESTIC_PASSWORD", false),
+		// Optional variables based on what backend is used for restic
+		utils.EnvFromSecret(secretName, "AWS_ACCESS_KEY_ID", true),
+		utils.EnvFromSecret(secretName, "AWS_SECRET_ACCESS_KEY", true),
+		utils.EnvFromSecret(secretName, "AWS_DEFAULT_REGION", true),
+		utils.EnvFromSecret(secretName, "ST_AUTH", true),
+		utils.EnvFromSecret(secretName, "ST_USER", true),
+		utils.EnvFromSecret(secretName, "ST_KEY", true),
+		utils.EnvFromSecret(secretName, "OS_AUTH_URL", true),
+		utils.EnvFromSecret(secretName, "OS_REGION_NAME", true),
+		utils.EnvFromSecret(secretName, "OS_USERNAME", true),
+		utils.EnvFromSecret(secretName, "OS_USER_ID", true),
+		utils.EnvFromSecret(secretName, "OS_PASSWORD", true),
+		utils.EnvFromSecret(secretName, "OS_TENANT_ID", true),
+		utils.EnvFromSecret(secretName, "OS_TENANT_NAME", true),
+		utils.EnvFromSecret(secretName, "OS_USER_DOMAIN_NAME", true),
+		utils.EnvFromSecret(secretName, "OS_USER_DOMAIN_ID", true),
+		utils.EnvFromSecret(secretName, "OS_PROJECT_NAME", true),
+		utils.EnvFromSecret(secretName, "OS_PROJECT_DOMAIN_NAME", true),
+		utils.EnvFromSecret(secretName, "OS_PROJECT_DOMAIN_ID", true),
+		utils.EnvFromSecret(secretName, "OS_TRUST_ID", true),
+		utils.EnvFromSecret(secretName, "OS_APPLICATION_CREDENTIAL_ID", true),
+		utils.EnvFromSecret(secretName, "OS_APPLICATION_CREDENTIAL_NAME", true),
+		utils.EnvFromSecret(secretName, "OS_APPLICATION_CREDENTIAL_SECRET", true),
+		utils.EnvFromSecret(secretName, "OS_STORAGE_URL", true),
+		utils.EnvFromSecret(secretName, "OS_AUTH_TOKEN", true),
+		utils.EnvFromSecret(secretName, "B2_ACCOUNT_ID", true),
+		utils.EnvFromSecret(secretName, "B2_ACCOUNT_KEY", true),
+		utils.EnvFromSecret(secretName, "AZURE_ACCOUNT_NAME", true),
+		utils.EnvFromSecret(secretName, "AZURE_ACCOUNT_KEY", true),
+		utils.EnvFromSecret(secretName, "GOOGLE_PROJECT_ID", true),
+		utils.EnvFromSecret(secretName, "GOOGLE_APPLICATION_CREDENTIALS", true),
+	}
+}
+
+// moverResult is the information reported by the mover via its termination
+// message
+type moverResult struct {
+	Repository volsyncv1alpha1.ResticRepositoryStateType
+	Snapshots  []volsyncv1alpha1.ResticSnapshot
+}
+
+// processMoverResult publishes the result that was written to the termination
+// message of a mover Pod in the given phase.
+func (m *Mover) processMoverResult(ctx context.Context, job *batchv1.Job, phase v1.PodPhase) error {
+	pods := &v1.PodList{}
+	if err := m.client.List(ctx, pods, client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != phase {
+			continue
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name != "restic" || cs.State.Terminated == nil {
+				continue
+			}
+			result, err := parseMoverResult(cs.State.Terminated.Message)
+			if err != nil {
+				return err
+			}
+			if result.Repository != "" {
+				m.setRepositoryState(result.Repository)
+			}
+			if m.isSource && result.Snapshots != nil {
+				m.sourceStatus.Snapshots = result.Snapshots
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *Mover) setRepositoryState(state volsyncv1alpha1.ResticRepositoryStateType) {
+	if m.isSource {
+		m.sourceStatus.RepositoryState = state
+	} else {
+		m.destStatus.RepositoryState = state
+	}
+}
+
+// parseMoverResult converts the JSON result emitted by the mover into the form
+// used in the CR status. An empty message (e.g., the backup was skipped)
+// results in an empty result.
+func parseMoverResult(message string) (*moverResult, error) {
+	result := &moverResult{}
+	if len(message) == 0 {
+		return result, nil
+	}
+	var raw struct {
+		Repository volsyncv1alpha1.ResticRepositoryStateType `json:"repository"`
+		Snapshots  *[]struct {
+			ID    string    `json:"id"`
+			Time  time.Time `json:"time"`
+			Size  *int64    `json:"size"`
+			Paths []string  `json:"paths"`
+			Tags  []string  `json:"tags"`
+		} `json:"snapshots"`
+	}
+	if err := json.Unmarshal([]byte(message), &raw); err != nil {
+		return nil, fmt.Errorf("unable to parse mover result: %w", err)
+	}
+	result.Repository = raw.Repository
+	if raw.Snapshots == nil {
+		return result, nil
+	}
+	entries := *raw.Snapshots
+	if len(entries) > maxSnapshotsInStatus {
+		entries = entries[:maxSnapshotsInStatus]
+	}
+	result.Snapshots = make([]volsyncv1alpha1.ResticSnapshot, 0, len(entries))
+	for _, e := range entries {
+		snap := volsyncv1alpha1.ResticSnapshot{
+			ID:    e.ID,
+			Paths: e.Paths,
+			Tags:  e.Tags,
+		}
+		if !e.Time.IsZero() {
+			snap.Time = &metav1.Time{Time: e.Time}
+		}
+		if e.Size != nil {
+			snap.Size = resource.NewQuantity(*e.Size, resource.BinarySI)
+		}
+		result.Snapshots = append(result.Snapshots, snap)
+	}
+	return result, nil
+}
+
+// recordVerification saves the outcome of a test restore Job in the status
+func (m *Mover) recordVerification(job *batchv1.Job, passed bool) {
+	now := metav1.Now()
+	result := &volsyncv1alpha1.ResticVerificationStatus{
+		Time:   &now,
+		Passed: passed,
+	}
+	if job.Status.StartTime != nil {
+		end := now
+		if job.Status.CompletionTime != nil {
+			end = *job.Status.CompletionTime
+		}
+		result.Duration = &metav1.Duration{Duration: end.Sub(job.Status.StartTime.Time)}
+	}
+	m.destStatus.LastVerification = result
+}
+
+// validateRestoreOptions checks the restore options that can't be fully
+// validated by the CRD schema.
+func (m *Mover) validateRestoreOptions() error {
+	if m.targetSubdirectory == nil {
+		return nil
+	}
+	dir := path.Clean(*m.targetSubdirectory)
+	if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return fmt.Errorf("targetSubdirectory must be a path within the destination volume: %v",
+			*m.targetSubdirectory)
+	}
+	return nil
+}
+
+// restoreEnv generates the environment variables that pass the restore options
+// to the mover. Lists of patterns are newline-separated.
+func (m *Mover) restoreEnv() []v1.EnvVar {
+	snapshot := "latest"
+	if m.restoreSnapshot != nil && *m.restoreSnapshot != "" {
+		snapshot = *m.restoreSnapshot
+	}
+	cleanup := volsyncv1alpha1.ResticTargetCleanupNone
+	if m.targetCleanup != nil {
+		cleanup = *m.targetCleanup
+	}
+	subdir := ""
+	if m.targetSubdirectory != nil {
+		subdir = path.Clean(*m.targetSubdirectory)
+	}
+	return []v1.EnvVar{
+		{Name: "RESTORE_SNAPSHOT", Value: snapshot},
+		{Name: "RESTORE_CLEANUP", Value: string(cleanup)},
+		{Name: "RESTORE_INCLUDE", Value: strings.Join(m.restoreInclude, "\n")},
+		{Name: "RESTORE_EXCLUDE", Value: strings.Join(m.restoreExclude, "\n")},
+		{Name: "RESTORE_SUBDIR", Value: subdir},
+		{Name: "RESTORE_VERIFY", Value: fmt.Sprint(m.verifyRestore)},
+	}
+}
+
 func (m *Mover) shouldPrune(current time.Time) bool {
 	delta := time.Hour * 24 * 7 // default prune every 7 days
 	if m.pruneInterval != nil {
@@ -25,6 +25,7 @@ import (
 	. "github.com/onsi/gomega"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -88,6 +89,60 @@ var _ = Describe("Restic retain policy", func() {
 	})
 })
 
+var _ = Describe("Restic mover result", func() {
+	When("the mover didn't report anything", func() {
+		It("leaves the result unset", func() {
+			result, err := parseMoverResult("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Repository).To(BeEmpty())
+			Expect(result.Snapshots).To(BeNil())
+		})
+	})
+	When("the mover reports the repository state", func() {
+		It("is recorded", func() {
+			result, err := parseMoverResult(`{"repository":"Created"}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Repository).To(Equal(volsyncv1alpha1.ResticRepositoryCreated))
+			Expect(result.Snapshots).To(BeNil())
+		})
+	})
+	When("the mover reports snapshots", func() {
+		It("converts them for the status", func() {
+			msg := `{"repository":"Found","snapshots":[` +
+				`{"id":"4b2f6bb1","time":"2021-06-01T10:20:30.123456789Z","paths":["/data"],"tags":[],"size":1048576},` +
+				`{"id":"a04d1e58","time":"2021-05-31T10:20:30Z","paths":["/data"],"tags":["x"]}]}`
+			result, err := parseMoverResult(msg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Repository).To(Equal(volsyncv1alpha1.ResticRepositoryFound))
+			snaps := result.Snapshots
+			Expect(snaps).To(HaveLen(2))
+			Expect(snaps[0].ID).To(Equal("4b2f6bb1"))
+			Expect(snaps[0].Time.Time.Equal(time.Date(2021, 6, 1, 10, 20, 30, 123456789, time.UTC))).To(BeTrue())
+			Expect(snaps[0].Size.Cmp(resource.MustParse("1Mi"))).To(Equal(0))
+			Expect(snaps[0].Paths).To(ConsistOf("/data"))
+			Expect(snaps[1].Size).To(BeNil())
+			Expect(snaps[1].Tags).To(ConsistOf("x"))
+		})
+		It("limits the number of entries", func() {
+			msg := `{"snapshots":[`
+			for i := 0; i < maxSnapshotsInStatus+5; i++ {
+				if i > 0 {
+					msg += ","
+				}
+				msg += `{"id":"abc"}`
+			}
+			msg += "]}"
+			result, err := parseMoverResult(msg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Snapshots).To(HaveLen(maxSnapshotsInStatus))
+		})
+		It("rejects malformed output", func() {
+			_, err := parseMoverResult("not json")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
 var _ = Describe("Restic prune policy", func() {
 	var m *Mover
 	var owner *v1.ConfigMap
@@ -490,7 +545,7 @@ var _ = Describe("Restic as a source", func() {
 				Expect(k8sClient.Create(ctx, repo)).To(Succeed())
 			})
 			When("it's the initial sync", func() {
-				It("should have only the backup action", func() {
+				It("should have only the init and backup actions", func() {
 					j, e := mover.ensureJob(ctx, cache, sPVC, sa, repo)
 					Expect(e).NotTo(HaveOccurred())
 					Expect(j).To(BeNil()) // hasn't completed
@@ -502,7 +557,12 @@ var _ = Describe("Restic as a source", func() {
 					}).Should(Succeed())
 					Expect(len(job.Spec.Template.Spec.Containers)).To(BeNumerically(">", 0))
 					args := job.Spec.Template.Spec.Containers[0].Args
-					Expect(args).To(ConsistOf("backup"))
+					Expect(args).To(ConsistOf("init", "backup"))
+					// Snapshots are tagged so the source can remove only its own
+					Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(v1.EnvVar{
+						Name:  "SOURCE_TAG",
+						Value: "volsync-source-" + string(rs.UID),
+					}))
 				})
 				It("should use the specified container image", func() {
 					j, e := mover.ensureJob(ctx, cache, sPVC, sa, repo)
@@ -564,6 +624,23 @@ var _ = Describe("Restic as a source", func() {
 
 				})
 			})
+			When("repository initialization is disabled", func() {
+				BeforeEach(func() {
+					policy := volsyncv1alpha1.ResticInitializeNever
+					rs.Spec.Restic.Initialize = &policy
+				})
+				It("should skip the init action", func() {
+					j, e := mover.ensureJob(ctx, cache, sPVC, sa, repo)
+					Expect(e).NotTo(HaveOccurred())
+					Expect(j).To(BeNil()) // hasn't completed
+					nsn := types.NamespacedName{Name: jobName, Namespace: ns.Name}
+					job = &batchv1.Job{}
+					Eventually(func() error {
+						return k8sClient.Get(ctx, nsn, job)
+					}, timeout, interval).Should(Succeed())
+					Expect(job.Spec.Template.Spec.Containers[0].Args).To(ConsistOf("backup"))
+				})
+			})
 			When("it's time to prune", func() {
 				var lastMonth metav1.Time
 				JustBeforeEach(func() {
@@ -574,7 +651,7 @@ var _ = Describe("Restic as a source", func() {
 						LastPruned: &lastMonth,
 					}
 				})
-				It("should have the backup and prune actions", func() {
+				It("should have the init, backup, and prune actions", func() {
 					j, e := mover.ensureJob(ctx, cache, sPVC, sa, repo)
 					Expect(e).NotTo(HaveOccurred())
 					Expect(j).To(BeNil()) // hasn't completed
@@ -587,7 +664,7 @@ var _ = Describe("Restic as a source", func() {
 					Expect(mover.shouldPrune(time.Now())).To(BeTrue())
 					Expect(len(job.Spec.Template.Spec.Containers)).To(BeNumerically(">", 0))
 					args := job.Spec.Template.Spec.Containers[0].Args
-					Expect(args).To(ConsistOf("backup", "prune"))
+					Expect(args).To(ConsistOf("init", "backup", "prune"))
 					// Mark completed
 					job.Status.Succeeded = int32(1)
 					Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
@@ -625,6 +702,101 @@ var _ = Describe("Restic as a source", func() {
 					}, timeout, interval).Should(Equal(int32(0)))
 				})
 			})
+			When("job options are specified", func() {
+				BeforeEach(func() {
+					backoff := int32(2)
+					attempt := int64(600)
+					sync := int64(3600)
+					rs.Spec.Restic.BackoffLimit = &backoff
+					rs.Spec.Restic.AttemptTimeoutSeconds = &attempt
+					rs.Spec.Restic.SyncTimeoutSeconds = &sync
+				})
+				It("should use them in the Job", func() {
+					j, e := mover.ensureJob(ctx, cache, sPVC, sa, repo)
+					Expect(e).NotTo(HaveOccurred())
+					Expect(j).To(BeNil()) // hasn't completed
+					nsn := types.NamespacedName{Name: jobName, Namespace: ns.Name}
+					job = &batchv1.Job{}
+					Eventually(func() error {
+						return k8sClient.Get(ctx, nsn, job)
+					}, timeout, interval).Should(Succeed())
+					Expect(*job.Spec.BackoffLimit).To(Equal(int32(2)))
+					Expect(*job.Spec.ActiveDeadlineSeconds).To(Equal(int64(3600)))
+					Expect(*job.Spec.Template.Spec.ActiveDeadlineSeconds).To(Equal(int64(600)))
+				})
+				It("should report when the deadline is exceeded", func() {
+					j, e := mover.ensureJob(ctx, cache, sPVC, sa, repo)
+					Expect(e).NotTo(HaveOccurred())
+					Expect(j).To(BeNil()) // hasn't completed
+					nsn := types.NamespacedName{Name: jobName, Namespace: ns.Name}
+					job = &batchv1.Job{}
+					Eventually(func() error {
+						if err := k8sClient.Get(ctx, nsn, job); err != nil {
+							return err
+						}
+						job.Status.Conditions = []batchv1.JobCondition{{
+							Type:   batchv1.JobFailed,
+							Status: v1.ConditionTrue,
+							Reason: "DeadlineExceeded",
+						}}
+						return k8sClient.Status().Update(ctx, job)
+					}, timeout, interval).Should(Succeed())
+					j, e = mover.ensureJob(ctx, cache, sPVC, sa, repo)
+					Expect(j).To(BeNil())
+					Expect(e).To(MatchError(ContainSubstring("deadline exceeded")))
+				})
+			})
+		})
+
+		Context("repository data is removed on deletion", func() {
+			When("the repository Secret is missing", func() {
+				BeforeEach(func() {
+					rs.Spec.Restic.Repository = "missing"
+				})
+				It("gives up", func() {
+					result, err := mover.Finalize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.Completed).To(BeTrue())
+				})
+			})
+			When("the repository Secret exists", func() {
+				BeforeEach(func() {
+					repo := &v1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "repo",
+							Namespace: ns.Name,
+						},
+						Data: map[string][]byte{
+							"RESTIC_REPOSITORY": []byte("s3:example"),
+							"RESTIC_PASSWORD":   []byte("password"),
+						},
+					}
+					Expect(k8sClient.Create(ctx, repo)).To(Succeed())
+					rs.Spec.Restic.Repository = repo.Name
+				})
+				It("runs a Job that deletes the snapshots", func() {
+					result, err := mover.Finalize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.Completed).To(BeFalse())
+					job := &batchv1.Job{}
+					nsn := types.NamespacedName{Name: "volsync-delete-" + rs.Name, Namespace: ns.Name}
+					Expect(k8sClient.Get(ctx, nsn, job)).To(Succeed())
+					Expect(job.Spec.Template.Spec.Containers[0].Args).To(ConsistOf("delete"))
+					Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(v1.EnvVar{
+						Name:  "SOURCE_TAG",
+						Value: "volsync-source-" + string(rs.UID),
+					}))
+					Expect(job.Spec.Template.Spec.Volumes).To(HaveLen(1))
+					Expect(job.Spec.Template.Spec.Volumes[0].EmptyDir).NotTo(BeNil())
+					// Mark completed
+					job.Status.Succeeded = int32(1)
+					Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+					Eventually(func() bool {
+						result, err = mover.Finalize(ctx)
+						return result.Completed && err == nil
+					}, timeout, interval).Should(BeTrue())
+				})
+			})
 		})
 	})
 })
@@ -741,6 +913,31 @@ var _ = Describe("Restic as a destination", func() {
 				Expect(sa2.Name).To(Equal(sa.Name))
 			})
 		})
+		When("an existing service account is specified", func() {
+			var userSA *v1.ServiceAccount
+			BeforeEach(func() {
+				userSA = &v1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-sa",
+						Namespace: ns.Name,
+					},
+				}
+				Expect(k8sClient.Create(ctx, userSA)).To(Succeed())
+				rd.Spec.MoverServiceAccount = &userSA.Name
+			})
+			It("is used instead of creating one", func() {
+				sa, err := mover.ensureSA(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sa).NotTo(BeNil())
+				Expect(sa.Name).To(Equal(userSA.Name))
+				created := &v1.ServiceAccount{}
+				err = k8sClient.Get(ctx, types.NamespacedName{
+					Name:      "volsync-dst-" + rd.Name,
+					Namespace: ns.Name,
+				}, created)
+				Expect(kerrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
 		Context("mover Job is handled properly", func() {
 			var jobName string
 			var dPVC *v1.PersistentVolumeClaim
@@ -805,7 +1002,7 @@ var _ = Describe("Restic as a destination", func() {
 				Expect(k8sClient.Create(ctx, repo)).To(Succeed())
 			})
 			When("it's the initial sync", func() {
-				It("should have only the restore action", func() {
+				It("should have only the init and restore actions", func() {
 					j, e := mover.ensureJob(ctx, cache, dPVC, sa, repo)
 					Expect(e).NotTo(HaveOccurred())
 					Expect(j).To(BeNil()) // hasn't completed
@@ -817,9 +1014,120 @@ var _ = Describe("Restic as a destination", func() {
 					}).Should(Succeed())
 					Expect(len(job.Spec.Template.Spec.Containers)).To(BeNumerically(">", 0))
 					args := job.Spec.Template.Spec.Containers[0].Args
-					Expect(args).To(ConsistOf("restore"))
+					Expect(args).To(ConsistOf("init", "restore"))
+				})
+			})
+			When("restore options are specified", func() {
+				BeforeEach(func() {
+					snap := "4e5d2b1a"
+					cleanup := volsyncv1alpha1.ResticTargetCleanupDeleteExtraneous
+					subdir := "data/./restored"
+					rd.Spec.Restic.Snapshot = &snap
+					rd.Spec.Restic.TargetCleanup = &cleanup
+					rd.Spec.Restic.Include = []string{"/app", "/etc"}
+					rd.Spec.Restic.Exclude = []string{"*.tmp"}
+					rd.Spec.Restic.TargetSubdirectory = &subdir
+					rd.Spec.Restic.Verify = true
+				})
+				It("passes them to the mover", func() {
+					j, e := mover.ensureJob(ctx, cache, dPVC, sa, repo)
+					Expect(e).NotTo(HaveOccurred())
+					Expect(j).To(BeNil()) // hasn't completed
+					nsn := types.NamespacedName{Name: jobName, Namespace: ns.Name}
+					job = &batchv1.Job{}
+					Eventually(func() error {
+						return k8sClient.Get(ctx, nsn, job)
+					}).Should(Succeed())
+					env := map[string]string{}
+					for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+						env[e.Name] = e.Value
+					}
+					Expect(env).To(HaveKeyWithValue("RESTORE_SNAPSHOT", "4e5d2b1a"))
+					Expect(env).To(HaveKeyWithValue("RESTORE_CLEANUP", "DeleteExtraneous"))
+					Expect(env).To(HaveKeyWithValue("RESTORE_INCLUDE", "/app\n/etc"))
+					Expect(env).To(HaveKeyWithValue("RESTORE_EXCLUDE", "*.tmp"))
+					Expect(env).To(HaveKeyWithValue("RESTORE_SUBDIR", "data/restored"))
+					Expect(env).To(HaveKeyWithValue("RESTORE_VERIFY", "true"))
 				})
 			})
+			When("restore options are omitted", func() {
+				It("restores the latest snapshot without cleanup", func() {
+					env := map[string]string{}
+					for _, e := range mover.restoreEnv() {
+						env[e.Name] = e.Value
+					}
+					Expect(env).To(HaveKeyWithValue("RESTORE_SNAPSHOT", "latest"))
+					Expect(env).To(HaveKeyWithValue("RESTORE_CLEANUP", "None"))
+					Expect(env).To(HaveKeyWithValue("RESTORE_SUBDIR", ""))
+					Expect(env).To(HaveKeyWithValue("RESTORE_VERIFY", "false"))
+				})
+			})
+		})
+		When("verify mode is selected", func() {
+			BeforeEach(func() {
+				mode := volsyncv1alpha1.ResticDestinationModeVerify
+				rd.Spec.Restic.Mode = &mode
+				rd.Spec.Restic.AccessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}
+				capacity := resource.MustParse("2Gi")
+				rd.Spec.Restic.Capacity = &capacity
+			})
+			It("restores into a temporary volume", func() {
+				pvc, e := mover.ensureVerifyPVC(ctx)
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pvc).NotTo(BeNil())
+				Expect(pvc.Labels).To(HaveKey("volsync.backube/cleanup"))
+			})
+			It("records the outcome of the verification", func() {
+				cache := &v1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: "thecache", Namespace: ns.Name},
+				}
+				pvc, e := mover.ensureVerifyPVC(ctx)
+				Expect(e).NotTo(HaveOccurred())
+				sa := &v1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{Name: "thesa", Namespace: ns.Name},
+				}
+				repo := &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: ns.Name},
+				}
+				j, e := mover.ensureJob(ctx, cache, pvc, sa, repo)
+				Expect(e).NotTo(HaveOccurred())
+				Expect(j).To(BeNil()) // hasn't completed
+				nsn := types.NamespacedName{Name: "volsync-dst-" + rd.Name, Namespace: ns.Name}
+				job := &batchv1.Job{}
+				Eventually(func() error {
+					if err := k8sClient.Get(ctx, nsn, job); err != nil {
+						return err
+					}
+					job.Status.Failed = *job.Spec.BackoffLimit
+					return k8sClient.Status().Update(ctx, job)
+				}, timeout, interval).Should(Succeed())
+				Expect(job.Spec.Template.Spec.Containers[0].Args).To(ConsistOf("init", "verify"))
+				Eventually(func() *batchv1.Job {
+					j, e = mover.ensureJob(ctx, cache, pvc, sa, repo)
+					Expect(e).NotTo(HaveOccurred())
+					return j
+				}, timeout, interval).ShouldNot(BeNil())
+				Expect(mover.destStatus.LastVerification).NotTo(BeNil())
+				Expect(mover.destStatus.LastVerification.Passed).To(BeFalse())
+			})
+		})
+		When("a target subdirectory is specified", func() {
+			var subdir string
+			JustBeforeEach(func() {
+				mover.targetSubdirectory = &subdir
+			})
+			It("accepts a relative path within the volume", func() {
+				subdir = "a/b/../c"
+				Expect(mover.validateRestoreOptions()).To(Succeed())
+			})
+			It("rejects an absolute path", func() {
+				subdir = "/etc"
+				Expect(mover.validateRestoreOptions()).NotTo(Succeed())
+			})
+			It("rejects a path that escapes the volume", func() {
+				subdir = "a/../../b"
+				Expect(mover.validateRestoreOptions()).NotTo(Succeed())
+			})
 		})
 	})
 })
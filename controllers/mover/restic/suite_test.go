@@ -18,10 +18,10 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package restic
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -34,6 +34,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/utils"
 	//sc "github.com/backube/volsync/controllers"
 	//+kubebuilder:scaffold:imports
 )
@@ -62,13 +63,20 @@ func TestAPIs(t *testing.T) {
 var _ = BeforeSuite(func(done Done) {
 	logf.SetLogger(zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter)))
 
+	// The snapshot API version to test against may be selected with
+	// SNAPSHOT_API_VERSION (v1 or v1beta1)
+	snapshotAPI := os.Getenv("SNAPSHOT_API_VERSION")
+	if snapshotAPI == "" {
+		snapshotAPI = utils.SnapshotV1.Version
+	}
+
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
 		CRDDirectoryPaths: []string{
 			// VolSync CRDs
 			filepath.Join("..", "..", "..", "config", "crd", "bases"),
 			// Snapshot CRDs
-			filepath.Join("..", "..", "..", "hack", "crds"),
+			filepath.Join("..", "..", "..", "hack", "crds", snapshotAPI),
 		},
 		ErrorIfCRDPathMissing: true,
 	}
@@ -81,7 +89,10 @@ var _ = BeforeSuite(func(done Done) {
 	err = volsyncv1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
-	err = snapv1.AddToScheme(scheme.Scheme)
+	snapVersion, err := utils.DetectSnapshotVersion(cfg)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(snapVersion.Version).To(Equal(snapshotAPI))
+	err = utils.AddSnapshotToScheme(scheme.Scheme, snapVersion)
 	Expect(err).NotTo(HaveOccurred())
 
 	//+kubebuilder:scaffold:scheme
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rsynctls
+
+import (
+	"flag"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/mover"
+	"github.com/backube/volsync/controllers/volumehandler"
+)
+
+// defaultRsyncTLSContainerImage is the default container image for the
+// rsync-over-TLS data mover
+const defaultRsyncTLSContainerImage = "quay.io/backube/volsync-mover-rsync:latest"
+
+// rsyncTLSContainerImage is the container image name of the rsync-over-TLS
+// data mover
+var rsyncTLSContainerImage string
+
+type Builder struct{}
+
+var _ mover.Builder = &Builder{}
+
+func Register() {
+	flag.StringVar(&rsyncTLSContainerImage, "rsync-tls-container-image",
+		defaultRsyncTLSContainerImage, "The container image for the rsync-over-TLS data mover")
+	mover.Register(&Builder{})
+}
+
+func (rb *Builder) FromSource(client client.Client, logger logr.Logger,
+	source *volsyncv1alpha1.ReplicationSource) (mover.Mover, error) {
+	// Only build if the CR belongs to us
+	if source.Spec.RsyncTLS == nil {
+		return nil, nil
+	}
+
+	vh, err := volumehandler.NewVolumeHandler(
+		volumehandler.WithClient(client),
+		volumehandler.WithOwner(source),
+		volumehandler.FromSource(&source.Spec.RsyncTLS.ReplicationSourceVolumeOptions),
+		volumehandler.SelectedCopyMethod(&source.Status.SelectedCopyMethod),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Mover{
+		client:      client,
+		logger:      logger.WithValues("method", "RsyncTLS"),
+		owner:       source,
+		vh:          vh,
+		isSource:    true,
+		paused:      source.Spec.Paused,
+		moverSA:     source.Spec.MoverServiceAccount,
+		mainPVCName: &source.Spec.SourcePVC,
+		keySecret:   source.Spec.RsyncTLS.KeySecret,
+		address:     source.Spec.RsyncTLS.Address,
+		port:        source.Spec.RsyncTLS.Port,
+	}, nil
+}
+
+func (rb *Builder) FromDestination(client client.Client, logger logr.Logger,
+	destination *volsyncv1alpha1.ReplicationDestination) (mover.Mover, error) {
+	// Only build if the CR belongs to us
+	if destination.Spec.RsyncTLS == nil {
+		return nil, nil
+	}
+
+	// Create ReplicationDestinationRsyncTLSStatus to write the connection info
+	if destination.Status.RsyncTLS == nil {
+		destination.Status.RsyncTLS = &volsyncv1alpha1.ReplicationDestinationRsyncTLSStatus{}
+	}
+
+	vh, err := volumehandler.NewVolumeHandler(
+		volumehandler.WithClient(client),
+		volumehandler.WithOwner(destination),
+		volumehandler.FromDestination(&destination.Spec.RsyncTLS.ReplicationDestinationVolumeOptions),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Mover{
+		client:      client,
+		logger:      logger.WithValues("method", "RsyncTLS"),
+		owner:       destination,
+		vh:          vh,
+		isSource:    false,
+		paused:      destination.Spec.Paused,
+		moverSA:     destination.Spec.MoverServiceAccount,
+		mainPVCName: destination.Spec.RsyncTLS.DestinationPVC,
+		keySecret:   destination.Spec.RsyncTLS.KeySecret,
+		serviceType: destination.Spec.RsyncTLS.ServiceType,
+		destStatus:  destination.Status.RsyncTLS,
+	}, nil
+}
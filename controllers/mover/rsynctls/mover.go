@@ -0,0 +1,456 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rsynctls
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/mover"
+	"github.com/backube/volsync/controllers/utils"
+	"github.com/backube/volsync/controllers/volumehandler"
+)
+
+const (
+	mountPath         = "/data"
+	dataVolumeName    = "data"
+	keyMountPath      = "/keys"
+	keyVolumeName     = "keys"
+	pskKey            = "psk.txt"
+	tlsContainerPort  = 8000
+	defaultTLSPort    = int32(8000)
+	pskIdentityPrefix = "volsync:"
+	// The unprivileged user and group that the mover runs as
+	moverUID = int64(65534)
+	moverGID = int64(65534)
+)
+
+// Mover is the reconciliation logic for the rsync-over-TLS data mover.
+type Mover struct {
+	client      client.Client
+	logger      logr.Logger
+	owner       metav1.Object
+	vh          *volumehandler.VolumeHandler
+	isSource    bool
+	paused      bool
+	moverSA     *string
+	mainPVCName *string
+	keySecret   *string
+	// Source-only fields
+	address *string
+	port    *int32
+	// Destination-only fields
+	serviceType *corev1.ServiceType
+	destStatus  *volsyncv1alpha1.ReplicationDestinationRsyncTLSStatus
+}
+
+var _ mover.Mover = &Mover{}
+
+// All object types that are temporary/per-iteration should be listed here. The
+// individual objects to be cleaned up must also be marked.
+var cleanupTypes = []client.Object{
+	&corev1.PersistentVolumeClaim{},
+	&snapv1.VolumeSnapshot{},
+	&batchv1.Job{},
+}
+
+func (m *Mover) Name() string { return "rsync-tls" }
+
+func (m *Mover) Synchronize(ctx context.Context) (mover.Result, error) {
+	var err error
+	// The source can't connect until it knows where the destination is
+	if m.isSource && (m.address == nil || *m.address == "") {
+		m.logger.Info("waiting for the destination address to be provided")
+		return mover.InProgress(), nil
+	}
+
+	// Allocate temporary data PVC
+	var dataPVC *corev1.PersistentVolumeClaim
+	if m.isSource {
+		dataPVC, err = m.ensureSourcePVC(ctx)
+	} else {
+		dataPVC, err = m.ensureDestinationPVC(ctx)
+	}
+	if dataPVC == nil || err != nil {
+		return mover.InProgress(), err
+	}
+	// The mover runs unprivileged, so it can't access a raw block device
+	if utils.IsBlockVolume(dataPVC) {
+		return mover.InProgress(), fmt.Errorf("PVC %v is a block volume, which is not supported by the rsyncTLS mover",
+			utils.NameFor(dataPVC))
+	}
+
+	// Ensure the pre-shared key is available
+	keys, err := m.ensureKeySecret(ctx)
+	if keys == nil || err != nil {
+		return mover.InProgress(), err
+	}
+
+	// On the destination, expose the mover to the source
+	if !m.isSource {
+		svc, err := m.ensureService(ctx)
+		if svc == nil || err != nil {
+			return mover.InProgress(), err
+		}
+	}
+
+	// Prepare ServiceAccount
+	sa, err := m.ensureSA(ctx)
+	if sa == nil || err != nil {
+		return mover.InProgress(), err
+	}
+
+	// Start mover Job
+	job, err := m.ensureJob(ctx, dataPVC, sa, keys)
+	if job == nil || err != nil {
+		return mover.InProgress(), err
+	}
+
+	// On the destination, preserve the image and return it
+	if !m.isSource {
+		image, err := m.vh.EnsureImage(ctx, m.logger, dataPVC)
+		if image == nil || err != nil {
+			return mover.InProgress(), err
+		}
+		return mover.CompleteWithImage(image), nil
+	}
+
+	// On the source, just signal completion
+	return mover.Complete(), nil
+}
+
+func (m *Mover) Cleanup(ctx context.Context) (mover.Result, error) {
+	err := utils.CleanupObjects(ctx, m.client, m.logger, m.owner, cleanupTypes)
+	if err != nil {
+		return mover.InProgress(), err
+	}
+	return mover.Complete(), nil
+}
+
+func (m *Mover) ensureSourcePVC(ctx context.Context) (*corev1.PersistentVolumeClaim, error) {
+	srcPVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *m.mainPVCName,
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	if err := m.client.Get(ctx, utils.NameFor(srcPVC), srcPVC); err != nil {
+		return nil, err
+	}
+	dataName := "volsync-" + m.owner.GetName() + "-src"
+	return m.vh.EnsurePVCFromSrc(ctx, m.logger, srcPVC, dataName, true)
+}
+
+func (m *Mover) ensureDestinationPVC(ctx context.Context) (*corev1.PersistentVolumeClaim, error) {
+	if m.mainPVCName == nil {
+		// Need to allocate the incoming data volume
+		dataPVCName := "volsync-" + m.owner.GetName() + "-dest"
+		return m.vh.EnsureNewPVC(ctx, m.logger, dataPVCName, false)
+	}
+
+	// use provided PVC
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *m.mainPVCName,
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	err := m.client.Get(ctx, utils.NameFor(pvc), pvc)
+	return pvc, err
+}
+
+// ensureKeySecret returns the Secret holding the pre-shared key. If one was
+// not provided for the destination, it is generated and its name is published
+// in the status so that it can be copied to the source.
+func (m *Mover) ensureKeySecret(ctx context.Context) (*corev1.Secret, error) {
+	if m.keySecret != nil {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      *m.keySecret,
+				Namespace: m.owner.GetNamespace(),
+			},
+		}
+		logger := m.logger.WithValues("keySecret", utils.NameFor(secret))
+		if err := utils.GetAndValidateSecret(ctx, m.client, logger, secret, pskKey); err != nil {
+			logger.Error(err, "key secret does not contain the proper fields")
+			return nil, err
+		}
+		if !m.isSource {
+			m.destStatus.KeySecret = &secret.Name
+		}
+		return secret, nil
+	}
+
+	if m.isSource {
+		// The source can't generate the key since the destination must have
+		// the same one
+		m.logger.Info("waiting for keySecret to be provided")
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "volsync-rsync-tls-" + m.owner.GetName(),
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	logger := m.logger.WithValues("keySecret", utils.NameFor(secret))
+	_, err := ctrlutil.CreateOrUpdate(ctx, m.client, secret, func() error {
+		if err := ctrl.SetControllerReference(m.owner, secret, m.client.Scheme()); err != nil {
+			logger.Error(err, "unable to set controller reference")
+			return err
+		}
+		// Only generate a key if one isn't already present
+		if len(secret.Data[pskKey]) > 0 {
+			return nil
+		}
+		psk, err := generatePSK()
+		if err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{pskKey: psk}
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "unable to reconcile key secret")
+		return nil, err
+	}
+	m.destStatus.KeySecret = &secret.Name
+	return secret, nil
+}
+
+// generatePSK creates a pre-shared key in the "identity:key" format that is
+// understood by stunnel
+func generatePSK() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return []byte(pskIdentityPrefix + hex.EncodeToString(key) + "\n"), nil
+}
+
+// ensureService maintains the Service that the source uses to connect to the
+// destination and publishes its address in the status
+func (m *Mover) ensureService(ctx context.Context) (*corev1.Service, error) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "volsync-rsync-tls-dst-" + m.owner.GetName(),
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	logger := m.logger.WithValues("service", utils.NameFor(svc))
+	_, err := ctrlutil.CreateOrUpdate(ctx, m.client, svc, func() error {
+		if err := ctrl.SetControllerReference(m.owner, svc, m.client.Scheme()); err != nil {
+			logger.Error(err, "unable to set controller reference")
+			return err
+		}
+		if m.serviceType != nil {
+			svc.Spec.Type = *m.serviceType
+		} else {
+			svc.Spec.Type = corev1.ServiceTypeClusterIP
+		}
+		svc.Spec.Selector = m.serviceSelector()
+		if len(svc.Spec.Ports) != 1 {
+			svc.Spec.Ports = []corev1.ServicePort{{}}
+		}
+		svc.Spec.Ports[0].Name = "rsync-tls"
+		svc.Spec.Ports[0].Port = defaultTLSPort
+		svc.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+		svc.Spec.Ports[0].TargetPort = intstr.FromInt(tlsContainerPort)
+		if svc.Spec.Type == corev1.ServiceTypeClusterIP {
+			svc.Spec.Ports[0].NodePort = 0
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "Service reconcile failed")
+		return nil, err
+	}
+
+	addresses, port, err := utils.ServiceAddresses(ctx, m.client, svc)
+	if len(addresses) == 0 || err != nil {
+		// We don't have an address yet, try again later
+		m.destStatus.Address = nil
+		m.destStatus.Addresses = nil
+		m.destStatus.Port = nil
+		return nil, err
+	}
+	m.destStatus.Address = &addresses[0]
+	m.destStatus.Addresses = addresses
+	m.destStatus.Port = &port
+	logger.V(1).Info("Service addr published", "addresses", addresses, "port", port)
+	return svc, nil
+}
+
+func (m *Mover) serviceSelector() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      "dst-" + m.owner.GetName(),
+		"app.kubernetes.io/component": "rsync-tls-mover",
+		"app.kubernetes.io/part-of":   "volsync",
+	}
+}
+
+func (m *Mover) ensureSA(ctx context.Context) (*corev1.ServiceAccount, error) {
+	dir := "src"
+	if !m.isSource {
+		dir = "dst"
+	}
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "volsync-" + dir + "-" + m.owner.GetName(),
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	saDesc := utils.NewSAHandler(ctx, m.client, m.owner, sa, m.moverSA)
+	cont, err := saDesc.Reconcile(m.logger)
+	if cont {
+		return sa, err
+	}
+	return nil, err
+}
+
+//nolint:funlen
+func (m *Mover) ensureJob(ctx context.Context, dataPVC *corev1.PersistentVolumeClaim,
+	sa *corev1.ServiceAccount, keys *corev1.Secret) (*batchv1.Job, error) {
+	dir := "src"
+	script := "/tls-source.sh"
+	if !m.isSource {
+		dir = "dst"
+		script = "/tls-destination.sh"
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "volsync-rsync-tls-" + dir + "-" + m.owner.GetName(),
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	logger := m.logger.WithValues("job", utils.NameFor(job))
+	_, err := ctrlutil.CreateOrUpdate(ctx, m.client, job, func() error {
+		if err := ctrl.SetControllerReference(m.owner, job, m.client.Scheme()); err != nil {
+			logger.Error(err, "unable to set controller reference")
+			return err
+		}
+		utils.MarkForCleanup(m.owner, job)
+		job.Spec.Template.ObjectMeta.Name = job.Name
+		if !m.isSource {
+			job.Spec.Template.ObjectMeta.Labels = m.serviceSelector()
+		}
+		backoffLimit := int32(2)
+		job.Spec.BackoffLimit = &backoffLimit
+		parallelism := int32(1)
+		if m.paused {
+			parallelism = int32(0)
+		}
+		job.Spec.Parallelism = &parallelism
+
+		env := []corev1.EnvVar{}
+		if m.isSource {
+			port := defaultTLSPort
+			if m.port != nil {
+				port = *m.port
+			}
+			env = append(env,
+				corev1.EnvVar{Name: "DESTINATION_ADDRESS", Value: *m.address},
+				corev1.EnvVar{Name: "DESTINATION_PORT", Value: strconv.Itoa(int(port))},
+			)
+		}
+
+		// Neither root nor any added capabilities are required since the
+		// rsync daemon doesn't chroot and listens on an unprivileged port.
+		// File ownership isn't preserved; the fsGroup gives the mover access
+		// to the volume.
+		allowPrivilegeEscalation := false
+		runAsNonRoot := true
+		uid, gid := moverUID, moverGID
+		job.Spec.Template.Spec.Containers = []corev1.Container{{
+			Name:    "rsync-tls",
+			Env:     env,
+			Command: []string{"/bin/bash", "-c", script},
+			Image:   rsyncTLSContainerImage,
+			Ports:   []corev1.ContainerPort{{Name: "rsync-tls", ContainerPort: tlsContainerPort}},
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+				Capabilities: &corev1.Capabilities{
+					Drop: []corev1.Capability{"ALL"},
+				},
+				RunAsNonRoot: &runAsNonRoot,
+				RunAsUser:    &uid,
+				RunAsGroup:   &gid,
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: dataVolumeName, MountPath: mountPath},
+				{Name: keyVolumeName, MountPath: keyMountPath},
+			},
+		}}
+		if m.isSource {
+			// The source doesn't listen for connections
+			job.Spec.Template.Spec.Containers[0].Ports = nil
+		}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.ServiceAccountName = sa.Name
+		job.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{FSGroup: &gid}
+		secretMode := int32(0600)
+		job.Spec.Template.Spec.Volumes = []corev1.Volume{
+			{Name: dataVolumeName, VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: dataPVC.Name,
+				}},
+			},
+			{Name: keyVolumeName, VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  keys.Name,
+					DefaultMode: &secretMode,
+				}},
+			},
+		}
+		return nil
+	})
+	// If Job had failed, delete it so it can be recreated
+	if job.Status.Failed >= *job.Spec.BackoffLimit {
+		logger.Info("deleting job -- backoff limit reached")
+		err = m.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		return nil, err
+	}
+	if err != nil {
+		logger.Error(err, "reconcile failed")
+		return nil, err
+	}
+
+	// Stop here if the job hasn't completed yet
+	if job.Status.Succeeded == 0 {
+		return nil, nil
+	}
+
+	logger.Info("job completed")
+	return job, nil
+}
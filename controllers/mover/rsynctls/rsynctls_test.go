@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rsynctls
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/mover"
+)
+
+var _ = Describe("RsyncTLS properly registers", func() {
+	When("RsyncTLS's registration function is called", func() {
+		BeforeEach(func() {
+			Register()
+		})
+		It("is added to the mover catalog", func() {
+			found := false
+			for _, v := range mover.Catalog {
+				if _, ok := v.(*Builder); ok {
+					found = true
+				}
+			}
+			Expect(found).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("RsyncTLS ignores other movers", func() {
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+	It("ignores an RS that isn't for rsync-tls", func() {
+		rs := &volsyncv1alpha1.ReplicationSource{
+			ObjectMeta: metav1.ObjectMeta{Name: "cr", Namespace: "blah"},
+		}
+		builder := Builder{}
+		m, e := builder.FromSource(k8sClient, logger, rs)
+		Expect(m).To(BeNil())
+		Expect(e).NotTo(HaveOccurred())
+	})
+	It("ignores an RD that isn't for rsync-tls", func() {
+		rd := &volsyncv1alpha1.ReplicationDestination{
+			ObjectMeta: metav1.ObjectMeta{Name: "x", Namespace: "y"},
+		}
+		builder := Builder{}
+		m, e := builder.FromDestination(k8sClient, logger, rd)
+		Expect(m).To(BeNil())
+		Expect(e).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("RsyncTLS pre-shared key", func() {
+	It("is in the format expected by stunnel", func() {
+		psk, err := generatePSK()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(psk)).To(MatchRegexp("^volsync:[0-9a-f]{64}\n$"))
+		psk2, err := generatePSK()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(psk2).NotTo(Equal(psk))
+	})
+})
+
+var _ = Describe("RsyncTLS as a destination", func() {
+	var ctx = context.TODO()
+	var ns *v1.Namespace
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+	var rd *volsyncv1alpha1.ReplicationDestination
+	var mover *Mover
+	BeforeEach(func() {
+		// Create namespace for test
+		ns = &v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "vh-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		Expect(ns.Name).NotTo(BeEmpty())
+
+		capacity := resource.MustParse("2Gi")
+		rd = &volsyncv1alpha1.ReplicationDestination{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rd",
+				Namespace: ns.Name,
+			},
+			Spec: volsyncv1alpha1.ReplicationDestinationSpec{
+				Trigger: &volsyncv1alpha1.ReplicationDestinationTriggerSpec{},
+				RsyncTLS: &volsyncv1alpha1.ReplicationDestinationRsyncTLSSpec{
+					ReplicationDestinationVolumeOptions: volsyncv1alpha1.ReplicationDestinationVolumeOptions{
+						AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+						Capacity:    &capacity,
+					},
+				},
+			},
+		}
+	})
+	JustBeforeEach(func() {
+		Expect(k8sClient.Create(ctx, rd)).To(Succeed())
+		// Controller sets status to non-nil
+		rd.Status = &volsyncv1alpha1.ReplicationDestinationStatus{}
+		b := Builder{}
+		m, err := b.FromDestination(k8sClient, logger, rd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m).NotTo(BeNil())
+		mover, _ = m.(*Mover)
+		Expect(mover).NotTo(BeNil())
+	})
+	AfterEach(func() {
+		// All resources are namespaced, so this should clean it all up
+		Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+	})
+
+	When("no key secret is provided", func() {
+		It("generates one and publishes it", func() {
+			secret, err := mover.ensureKeySecret(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret).NotTo(BeNil())
+			Expect(secret.Data).To(HaveKey(pskKey))
+			Expect(rd.Status.RsyncTLS.KeySecret).NotTo(BeNil())
+			Expect(*rd.Status.RsyncTLS.KeySecret).To(Equal(secret.Name))
+
+			// The key is stable across reconciles
+			again, err := mover.ensureKeySecret(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again.Data[pskKey]).To(Equal(secret.Data[pskKey]))
+		})
+	})
+	When("a key secret is provided", func() {
+		var secret *v1.Secret
+		BeforeEach(func() {
+			secret = &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "mykeys",
+					Namespace: ns.Name,
+				},
+				StringData: map[string]string{"wrong": "key"},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			rd.Spec.RsyncTLS.KeySecret = &secret.Name
+		})
+		It("must contain the pre-shared key", func() {
+			s, err := mover.ensureKeySecret(ctx)
+			Expect(err).To(HaveOccurred())
+			Expect(s).To(BeNil())
+
+			secret.StringData = map[string]string{pskKey: "volsync:abcd"}
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+			Eventually(func() error {
+				_, err := mover.ensureKeySecret(ctx)
+				return err
+			}, "5s", "1s").Should(Succeed())
+		})
+	})
+
+	It("exposes the mover via a Service", func() {
+		svc, err := mover.ensureService(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(svc).NotTo(BeNil())
+		Expect(svc.Spec.Type).To(Equal(v1.ServiceTypeClusterIP))
+		Expect(svc.Spec.Ports).To(HaveLen(1))
+		Expect(svc.Spec.Ports[0].Port).To(Equal(defaultTLSPort))
+		Expect(rd.Status.RsyncTLS.Address).NotTo(BeNil())
+		Expect(*rd.Status.RsyncTLS.Address).To(Equal(svc.Spec.ClusterIP))
+		Expect(rd.Status.RsyncTLS.Addresses).To(ContainElement(svc.Spec.ClusterIP))
+		Expect(rd.Status.RsyncTLS.Port).NotTo(BeNil())
+		Expect(*rd.Status.RsyncTLS.Port).To(Equal(defaultTLSPort))
+	})
+
+	It("runs the mover without elevated privileges", func() {
+		dataPVC, err := mover.ensureDestinationPVC(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		keys, err := mover.ensureKeySecret(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		sa, err := mover.ensureSA(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sa).NotTo(BeNil())
+		job, err := mover.ensureJob(ctx, dataPVC, sa, keys)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job).To(BeNil()) // hasn't completed
+
+		jobs := &batchv1.JobList{}
+		Expect(k8sClient.List(ctx, jobs, client.InNamespace(ns.Name))).To(Succeed())
+		Expect(jobs.Items).To(HaveLen(1))
+		podSpec := jobs.Items[0].Spec.Template
+		Expect(podSpec.Labels).To(Equal(mover.serviceSelector()))
+		c := podSpec.Spec.Containers[0]
+		Expect(*c.SecurityContext.RunAsNonRoot).To(BeTrue())
+		Expect(*c.SecurityContext.RunAsUser).NotTo(BeZero())
+		Expect(c.SecurityContext.Capabilities.Add).To(BeEmpty())
+		Expect(*c.SecurityContext.AllowPrivilegeEscalation).To(BeFalse())
+	})
+})
+
+var _ = Describe("RsyncTLS as a source", func() {
+	var ctx = context.TODO()
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+	It("waits for the destination address", func() {
+		keySecret := "keys"
+		rs := &volsyncv1alpha1.ReplicationSource{
+			ObjectMeta: metav1.ObjectMeta{Name: "rs", Namespace: "none"},
+			Spec: volsyncv1alpha1.ReplicationSourceSpec{
+				SourcePVC: "missing",
+				RsyncTLS: &volsyncv1alpha1.ReplicationSourceRsyncTLSSpec{
+					KeySecret: &keySecret,
+				},
+			},
+		}
+		b := Builder{}
+		m, err := b.FromSource(k8sClient, logger, rs)
+		Expect(err).NotTo(HaveOccurred())
+		result, err := m.Synchronize(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Completed).To(BeFalse())
+		// Nothing was created for the synchronization
+		jobs := &batchv1.JobList{}
+		Expect(k8sClient.List(ctx, jobs, client.InNamespace(rs.Namespace))).To(Succeed())
+		Expect(jobs.Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,195 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rsynctls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/utils"
+	//sc "github.com/backube/volsync/controllers"
+	//+kubebuilder:scaffold:imports
+)
+
+// These tests use Ginkgo (BDD-style Go testing framework). Refer to
+// http://onsi.github.io/ginkgo/ to learn more about Ginkgo.
+
+const (
+//duration = 10 * time.Second
+//maxWait  = 60 * time.Second
+//interval = 250 * time.Millisecond
+)
+
+var cfg *rest.Config
+var k8sClient client.Client
+var testEnv *envtest.Environment
+
+func TestAPIs(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t,
+		"RsyncTLS mover",
+		[]Reporter{printer.NewlineReporter{}})
+}
+
+var _ = BeforeSuite(func(done Done) {
+	logf.SetLogger(zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter)))
+
+	// The snapshot API version to test against may be selected with
+	// SNAPSHOT_API_VERSION (v1 or v1beta1)
+	snapshotAPI := os.Getenv("SNAPSHOT_API_VERSION")
+	if snapshotAPI == "" {
+		snapshotAPI = utils.SnapshotV1.Version
+	}
+
+	By("bootstrapping test environment")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			// VolSync CRDs
+			filepath.Join("..", "..", "..", "config", "crd", "bases"),
+			// Snapshot CRDs
+			filepath.Join("..", "..", "..", "hack", "crds", snapshotAPI),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	var err error
+	cfg, err = testEnv.Start()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(cfg).ToNot(BeNil())
+
+	err = volsyncv1alpha1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
+	snapVersion, err := utils.DetectSnapshotVersion(cfg)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(snapVersion.Version).To(Equal(snapshotAPI))
+	err = utils.AddSnapshotToScheme(scheme.Scheme, snapVersion)
+	Expect(err).NotTo(HaveOccurred())
+
+	//+kubebuilder:scaffold:scheme
+
+	/*
+		// From original boilerplate
+		k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(k8sClient).ToNot(BeNil())
+	*/
+
+	k8sManager, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:             scheme.Scheme,
+		MetricsBindAddress: "0",
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	// err = (&sc.ReplicationDestinationReconciler{
+	// 	Client: k8sManager.GetClient(),
+	// 	Log:    ctrl.Log.WithName("controllers").WithName("Destination"),
+	// 	Scheme: k8sManager.GetScheme(),
+	// }).SetupWithManager(k8sManager)
+	// Expect(err).ToNot(HaveOccurred())
+
+	// err = (&sc.ReplicationSourceReconciler{
+	// 	Client: k8sManager.GetClient(),
+	// 	Log:    ctrl.Log.WithName("controllers").WithName("Source"),
+	// 	Scheme: k8sManager.GetScheme(),
+	// }).SetupWithManager(k8sManager)
+	// Expect(err).ToNot(HaveOccurred())
+
+	go func() {
+		defer GinkgoRecover()
+		err = k8sManager.Start(ctrl.SetupSignalHandler())
+		Expect(err).ToNot(HaveOccurred())
+	}()
+
+	k8sClient = k8sManager.GetClient()
+	Expect(k8sClient).ToNot(BeNil())
+
+	close(done)
+}, 60)
+
+var _ = AfterSuite(func() {
+	By("tearing down the test environment")
+	err := testEnv.Stop()
+	Expect(err).ToNot(HaveOccurred())
+})
+
+/*
+// beOwnedBy is a GomegaMatcher that ensures a Kubernetes Object is owned by a
+// specific other object.
+func beOwnedBy(owner interface{}) gomegatypes.GomegaMatcher {
+	return &ownerRefMatcher{
+		owner: owner,
+	}
+}
+
+type ownerRefMatcher struct {
+	owner  interface{}
+	reason string
+}
+
+func (m *ownerRefMatcher) Match(actual interface{}) (success bool, err error) {
+	actObj, ok := actual.(metav1.Object)
+	if !ok {
+		return false, fmt.Errorf("actual value is not a metav1.Object")
+	}
+	ownerObj, ok := m.owner.(metav1.Object)
+	if !ok {
+		return false, fmt.Errorf("expected value is not a metav1.Object")
+	}
+	controller := metav1.GetControllerOf(actObj)
+	if controller == nil {
+		m.reason = "it does not have an owner"
+		return false, nil
+	}
+	if controller.UID != ownerObj.GetUID() {
+		m.reason = "it does not refer to the expected parent object"
+		return false, nil
+	}
+	// XXX: This check isn't perfect. Both cluster-scoped and objects in the
+	// "default" namespace have an empty namespace name. So the following may
+	// (incorrectly) pass for namespaced owners in the default namespace
+	// attempting to own cluster-scoped objects.
+	if ownerObj.GetNamespace() != "" { // if owner not cluster-scoped
+		if actObj.GetNamespace() != ownerObj.GetNamespace() {
+			m.reason = "cross namespace owner references are not allowed"
+			return false, nil
+		}
+	}
+	return true, nil
+}
+func (m *ownerRefMatcher) FailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("Expected\n\t%#v\nto be owned by\n\t%#v\nbut %v", actual, m.owner, m.reason)
+}
+func (m *ownerRefMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("Expected\n\t%#v\nnot to be owned by\n\t%#v", actual, m.owner)
+}
+*/
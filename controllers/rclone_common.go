@@ -0,0 +1,333 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/utils"
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// Field of the rclone config Secret that holds the configuration file
+	rcloneConfigField = "rclone.conf"
+	// Name of the section in a generated rclone configuration
+	rcloneGeneratedSection = "volsync"
+	// Prefix of the termination message of an rclone mover that was unable to
+	// restore the permissions of the files
+	rclonePermissionsPrefix = "permissions: "
+	// Prefix of the termination message of an rclone mover that reports the
+	// number of conflicts found by a bidirectional sync
+	rcloneConflictsPrefix = "conflicts: "
+	// Where the state of a bidirectional sync is mounted in the mover
+	rcloneBisyncStateMountPath = "/bisync-state"
+	rcloneBisyncStateVolume    = "bisync-state"
+)
+
+// getRclonePermissionsError returns the problem restoring permissions that was
+// reported by a successful mover Pod of the Job, or "" if there was none
+func getRclonePermissionsError(ctx context.Context, c client.Client, job *batchv1.Job) (string, error) {
+	return getRcloneMoverMessage(ctx, c, job, rclonePermissionsPrefix)
+}
+
+// getRcloneBisyncConflicts returns the number of conflicts reported by a
+// successful mover Pod of the Job, or nil if none was reported
+func getRcloneBisyncConflicts(ctx context.Context, c client.Client, job *batchv1.Job) (*int32, error) {
+	msg, err := getRcloneMoverMessage(ctx, c, job, rcloneConflictsPrefix)
+	if err != nil || msg == "" {
+		return nil, err
+	}
+	conflicts, err := strconv.ParseInt(strings.TrimSpace(msg), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse conflict count %q: %w", msg, err)
+	}
+	count := int32(conflicts)
+	return &count, nil
+}
+
+// getRcloneMoverMessage returns the termination message of a successful mover
+// Pod of the Job that has the given prefix (with the prefix removed), or "" if
+// there is none
+func getRcloneMoverMessage(ctx context.Context, c client.Client, job *batchv1.Job, prefix string) (string, error) {
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Terminated != nil && strings.HasPrefix(cs.State.Terminated.Message, prefix) {
+				return strings.TrimPrefix(cs.State.Terminated.Message, prefix), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// validateRcloneSpec checks that the remote is described either by an existing
+// rclone configuration (rcloneConfig and rcloneConfigSection) or by
+// rcloneBackend, but not both. All of the problems that are found are reported
+// in the returned error.
+func validateRcloneSpec(config, section, destPath *string, backend *volsyncv1alpha1.RcloneBackendSpec) error {
+	var problems []string
+	if destPath == nil || len(*destPath) == 0 {
+		problems = append(problems, "rcloneDestPath is required")
+	}
+	if backend != nil {
+		if config != nil && len(*config) > 0 {
+			problems = append(problems, "rcloneConfig cannot be used with rcloneBackend")
+		}
+		if section != nil && len(*section) > 0 {
+			problems = append(problems, "rcloneConfigSection cannot be used with rcloneBackend")
+		}
+		problems = append(problems, rcloneBackendProblems(backend)...)
+	} else {
+		if config == nil || len(*config) == 0 {
+			problems = append(problems, "rcloneConfig (or rcloneBackend) is required")
+		}
+		if section == nil || len(*section) == 0 {
+			problems = append(problems, "rcloneConfigSection (or rcloneBackend) is required")
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid rclone configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func missingSecretKey(sel *corev1.SecretKeySelector) bool {
+	return sel == nil || sel.Name == "" || sel.Key == ""
+}
+
+// rcloneBackendProblems returns the reasons the backend is invalid
+func rcloneBackendProblems(b *volsyncv1alpha1.RcloneBackendSpec) []string {
+	var problems []string
+	count := 0
+	if b.S3 != nil {
+		count++
+		if missingSecretKey(b.S3.AccessKeyID) {
+			problems = append(problems, "rcloneBackend.s3.accessKeyID is required")
+		}
+		if missingSecretKey(b.S3.SecretAccessKey) {
+			problems = append(problems, "rcloneBackend.s3.secretAccessKey is required")
+		}
+	}
+	if b.AzureBlob != nil {
+		count++
+		if b.AzureBlob.Account == "" {
+			problems = append(problems, "rcloneBackend.azureBlob.account is required")
+		}
+		if missingSecretKey(b.AzureBlob.Key) {
+			problems = append(problems, "rcloneBackend.azureBlob.key is required")
+		}
+	}
+	if b.GCS != nil {
+		count++
+		if missingSecretKey(b.GCS.ServiceAccountCredentials) {
+			problems = append(problems, "rcloneBackend.gcs.serviceAccountCredentials is required")
+		}
+	}
+	if b.SFTP != nil {
+		count++
+		if b.SFTP.Host == "" {
+			problems = append(problems, "rcloneBackend.sftp.host is required")
+		}
+		if b.SFTP.User == "" {
+			problems = append(problems, "rcloneBackend.sftp.user is required")
+		}
+		if missingSecretKey(b.SFTP.PrivateKey) {
+			problems = append(problems, "rcloneBackend.sftp.privateKey is required")
+		}
+	}
+	if b.Local != nil {
+		count++
+	}
+	if count != 1 {
+		problems = append(problems, "exactly one backend must be specified in rcloneBackend")
+	}
+	return problems
+}
+
+// secretKeyValue returns the value of the referenced Secret field
+func secretKeyValue(ctx context.Context, c client.Client, namespace string,
+	sel *corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: sel.Name, Namespace: namespace}, secret); err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[sel.Key]
+	if !ok {
+		return "", fmt.Errorf("secret %v is missing field: %v", sel.Name, sel.Key)
+	}
+	return string(value), nil
+}
+
+type rcloneOption struct {
+	key   string
+	value string
+}
+
+// rcloneBackendOptions returns the options of the rclone configuration section
+// that describes the backend, reading credentials from their Secrets.
+//nolint:funlen
+func rcloneBackendOptions(ctx context.Context, c client.Client, namespace string,
+	b *volsyncv1alpha1.RcloneBackendSpec) ([]rcloneOption, error) {
+	// secret looks up a credential, retaining the first error
+	var err error
+	secret := func(sel *corev1.SecretKeySelector) string {
+		if err != nil {
+			return ""
+		}
+		var value string
+		value, err = secretKeyValue(ctx, c, namespace, sel)
+		return strings.TrimSpace(value)
+	}
+
+	var opts []rcloneOption
+	switch {
+	case b.S3 != nil:
+		provider := "Other"
+		if b.S3.Provider != nil {
+			provider = *b.S3.Provider
+		}
+		opts = []rcloneOption{
+			{"type", "s3"},
+			{"provider", provider},
+			{"access_key_id", secret(b.S3.AccessKeyID)},
+			{"secret_access_key", secret(b.S3.SecretAccessKey)},
+		}
+		if b.S3.Endpoint != nil {
+			opts = append(opts, rcloneOption{"endpoint", *b.S3.Endpoint})
+		}
+		if b.S3.Region != nil {
+			opts = append(opts, rcloneOption{"region", *b.S3.Region})
+		}
+	case b.AzureBlob != nil:
+		opts = []rcloneOption{
+			{"type", "azureblob"},
+			{"account", b.AzureBlob.Account},
+			{"key", secret(b.AzureBlob.Key)},
+		}
+		if b.AzureBlob.Endpoint != nil {
+			opts = append(opts, rcloneOption{"endpoint", *b.AzureBlob.Endpoint})
+		}
+	case b.GCS != nil:
+		// The credentials must be on a single line
+		var creds bytes.Buffer
+		if credsJSON := secret(b.GCS.ServiceAccountCredentials); err == nil {
+			if err = json.Compact(&creds, []byte(credsJSON)); err != nil {
+				err = fmt.Errorf("invalid GCS service account credentials: %w", err)
+			}
+		}
+		opts = []rcloneOption{
+			{"type", "google cloud storage"},
+			{"service_account_credentials", creds.String()},
+			{"bucket_policy_only", strconv.FormatBool(b.GCS.BucketPolicyOnly)},
+		}
+		if b.GCS.ProjectNumber != nil {
+			opts = append(opts, rcloneOption{"project_number", *b.GCS.ProjectNumber})
+		}
+	case b.SFTP != nil:
+		port := int32(22)
+		if b.SFTP.Port != nil {
+			port = *b.SFTP.Port
+		}
+		// rclone expects the key on a single line, with escaped newlines
+		key := strings.ReplaceAll(strings.TrimSpace(secret(b.SFTP.PrivateKey)), "\n", `\n`)
+		opts = []rcloneOption{
+			{"type", "sftp"},
+			{"host", b.SFTP.Host},
+			{"port", strconv.Itoa(int(port))},
+			{"user", b.SFTP.User},
+			{"key_pem", key},
+		}
+	case b.Local != nil:
+		opts = []rcloneOption{{"type", "local"}}
+	default:
+		return nil, errors.New("no backend specified in rcloneBackend")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// renderRcloneConfig formats the options as an rclone configuration file with
+// a single section
+func renderRcloneConfig(section string, opts []rcloneOption) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s]\n", section)
+	for _, o := range opts {
+		fmt.Fprintf(&sb, "%s = %s\n", o.key, o.value)
+	}
+	return sb.String()
+}
+
+// ensureGeneratedRcloneConfig creates or updates the Secret, owned by owner,
+// that holds the rclone configuration generated from the backend.
+func ensureGeneratedRcloneConfig(ctx context.Context, c client.Client, scheme *runtime.Scheme,
+	l logr.Logger, owner metav1.Object, name string,
+	backend *volsyncv1alpha1.RcloneBackendSpec) (*corev1.Secret, error) {
+	opts, err := rcloneBackendOptions(ctx, c, owner.GetNamespace(), backend)
+	if err != nil {
+		l.Error(err, "unable to read rclone backend credentials")
+		return nil, err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: owner.GetNamespace(),
+		},
+	}
+	logger := l.WithValues("secret", utils.NameFor(secret))
+	op, err := ctrlutil.CreateOrUpdate(ctx, c, secret, func() error {
+		if err := ctrl.SetControllerReference(owner, secret, scheme); err != nil {
+			logger.Error(err, "unable to set controller reference")
+			return err
+		}
+		secret.Data = map[string][]byte{
+			rcloneConfigField: []byte(renderRcloneConfig(rcloneGeneratedSection, opts)),
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "reconcile failed")
+		return nil, err
+	}
+	logger.V(1).Info("rclone config reconciled", "operation", op)
+	return secret, nil
+}
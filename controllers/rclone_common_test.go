@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+)
+
+var _ = Describe("Rclone spec validation", func() {
+	config := "rclone-secret"
+	section := "mysection"
+	destPath := "bucket/path"
+	keyRef := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "creds"},
+		Key:                  "key",
+	}
+
+	It("accepts an existing configuration", func() {
+		Expect(validateRcloneSpec(&config, &section, &destPath, nil)).To(Succeed())
+	})
+	It("requires a configuration or a backend", func() {
+		err := validateRcloneSpec(nil, nil, &destPath, nil)
+		Expect(err).To(MatchError(ContainSubstring("rcloneConfig (or rcloneBackend) is required")))
+		Expect(err).To(MatchError(ContainSubstring("rcloneConfigSection (or rcloneBackend) is required")))
+	})
+	It("requires a destination path", func() {
+		Expect(validateRcloneSpec(&config, &section, nil, nil)).To(
+			MatchError(ContainSubstring("rcloneDestPath is required")))
+	})
+	It("accepts a complete backend", func() {
+		backend := &volsyncv1alpha1.RcloneBackendSpec{
+			S3: &volsyncv1alpha1.RcloneS3Backend{AccessKeyID: keyRef, SecretAccessKey: keyRef},
+		}
+		Expect(validateRcloneSpec(nil, nil, &destPath, backend)).To(Succeed())
+	})
+	It("reports all missing backend fields", func() {
+		backend := &volsyncv1alpha1.RcloneBackendSpec{
+			SFTP: &volsyncv1alpha1.RcloneSFTPBackend{Host: "sftp.example.com"},
+		}
+		err := validateRcloneSpec(nil, nil, &destPath, backend)
+		Expect(err).To(MatchError(ContainSubstring("rcloneBackend.sftp.user is required")))
+		Expect(err).To(MatchError(ContainSubstring("rcloneBackend.sftp.privateKey is required")))
+	})
+	It("doesn't permit both a configuration and a backend", func() {
+		backend := &volsyncv1alpha1.RcloneBackendSpec{Local: &volsyncv1alpha1.RcloneLocalBackend{}}
+		Expect(validateRcloneSpec(&config, nil, &destPath, backend)).To(
+			MatchError(ContainSubstring("rcloneConfig cannot be used with rcloneBackend")))
+	})
+	It("requires exactly one backend", func() {
+		Expect(validateRcloneSpec(nil, nil, &destPath, &volsyncv1alpha1.RcloneBackendSpec{})).To(
+			MatchError(ContainSubstring("exactly one backend")))
+		backend := &volsyncv1alpha1.RcloneBackendSpec{
+			Local: &volsyncv1alpha1.RcloneLocalBackend{},
+			GCS:   &volsyncv1alpha1.RcloneGCSBackend{ServiceAccountCredentials: keyRef},
+		}
+		Expect(validateRcloneSpec(nil, nil, &destPath, backend)).To(
+			MatchError(ContainSubstring("exactly one backend")))
+	})
+})
+
+var _ = Describe("Rclone config generation", func() {
+	var ctx = context.Background()
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+	var namespace *corev1.Namespace
+	var owner *corev1.ConfigMap
+
+	BeforeEach(func() {
+		namespace = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "volsync-test-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+		owner = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "owner",
+				Namespace: namespace.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, owner)).To(Succeed())
+		creds := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "creds",
+				Namespace: namespace.Name,
+			},
+			StringData: map[string]string{
+				"id":     "AKIAEXAMPLE\n",
+				"secret": "s3cr3t",
+				"key":    "-----BEGIN KEY-----\nabc\ndef\n-----END KEY-----\n",
+			},
+		}
+		Expect(k8sClient.Create(ctx, creds)).To(Succeed())
+	})
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, namespace)).To(Succeed())
+	})
+
+	ref := func(key string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "creds"},
+			Key:                  key,
+		}
+	}
+
+	It("renders an S3 backend with credentials from the Secret", func() {
+		endpoint := "https://minio.example.com"
+		backend := &volsyncv1alpha1.RcloneBackendSpec{
+			S3: &volsyncv1alpha1.RcloneS3Backend{
+				Endpoint:        &endpoint,
+				AccessKeyID:     ref("id"),
+				SecretAccessKey: ref("secret"),
+			},
+		}
+		secret, err := ensureGeneratedRcloneConfig(ctx, k8sClient, k8sClient.Scheme(), logger, owner,
+			"generated", backend)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret).To(beOwnedBy(owner))
+		Expect(string(secret.Data[rcloneConfigField])).To(Equal(`[volsync]
+type = s3
+provider = Other
+access_key_id = AKIAEXAMPLE
+secret_access_key = s3cr3t
+endpoint = https://minio.example.com
+`))
+	})
+	It("escapes the newlines of an SFTP key", func() {
+		backend := &volsyncv1alpha1.RcloneBackendSpec{
+			SFTP: &volsyncv1alpha1.RcloneSFTPBackend{
+				Host:       "sftp.example.com",
+				User:       "backup",
+				PrivateKey: ref("key"),
+			},
+		}
+		secret, err := ensureGeneratedRcloneConfig(ctx, k8sClient, k8sClient.Scheme(), logger, owner,
+			"generated", backend)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(secret.Data[rcloneConfigField])).To(ContainSubstring(
+			`key_pem = -----BEGIN KEY-----\nabc\ndef\n-----END KEY-----` + "\n"))
+	})
+	It("fails if a credential is missing", func() {
+		backend := &volsyncv1alpha1.RcloneBackendSpec{
+			AzureBlob: &volsyncv1alpha1.RcloneAzureBlobBackend{
+				Account: "myaccount",
+				Key:     ref("missing"),
+			},
+		}
+		_, err := ensureGeneratedRcloneConfig(ctx, k8sClient, k8sClient.Scheme(), logger, owner,
+			"generated", backend)
+		Expect(err).To(MatchError(ContainSubstring("missing field: missing")))
+	})
+})
+
+var _ = Describe("Rclone mover results", func() {
+	var ctx = context.Background()
+	var namespace *corev1.Namespace
+	var job *batchv1.Job
+	var pod *corev1.Pod
+
+	BeforeEach(func() {
+		namespace = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "volsync-test-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+		job = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mover",
+				Namespace: namespace.Name,
+			},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mover-pod",
+				Namespace: namespace.Name,
+				Labels:    map[string]string{"job-name": job.Name},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "rclone", Image: "quay.io/backube/volsync-mover-rclone"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+	})
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, namespace)).To(Succeed())
+	})
+
+	setResult := func(phase corev1.PodPhase, message string) {
+		pod.Status.Phase = phase
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name: "rclone",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{Message: message},
+			},
+		}}
+		Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+	}
+
+	It("returns the problem reported by the mover", func() {
+		setResult(corev1.PodSucceeded, "permissions: setfacl: ./data: Operation not supported")
+		Expect(getRclonePermissionsError(ctx, k8sClient, job)).To(
+			Equal("setfacl: ./data: Operation not supported"))
+	})
+	It("returns nothing if the permissions were restored", func() {
+		setResult(corev1.PodSucceeded, "")
+		Expect(getRclonePermissionsError(ctx, k8sClient, job)).To(BeEmpty())
+	})
+	It("returns the number of bisync conflicts", func() {
+		setResult(corev1.PodSucceeded, "conflicts: 3")
+		conflicts, err := getRcloneBisyncConflicts(ctx, k8sClient, job)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conflicts).NotTo(BeNil())
+		Expect(*conflicts).To(Equal(int32(3)))
+	})
+})
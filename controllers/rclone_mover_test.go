@@ -4,7 +4,7 @@ import (
 	"context"
 	"time"
 
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -17,6 +17,7 @@ import (
 	utils "github.com/backube/volsync/controllers/utils"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -251,9 +252,11 @@ var _ = Describe("ReplicationDestination [rclone]", func() {
 						}, maxWait, interval).Should(Not(BeEmpty()))
 						// update the VS name
 						snapshot := snapshots.Items[0]
+						ready := true
 						foo := "dummysnapshot"
 						snapshot.Status = &snapv1.VolumeSnapshotStatus{
 							BoundVolumeSnapshotContentName: &foo,
+							ReadyToUse:                     &ready,
 						}
 						Expect(k8sClient.Status().Update(ctx, &snapshot)).To(Succeed())
 						// wait for an image to be set for RD
@@ -512,6 +515,114 @@ var _ = Describe("ReplicationSource [rclone]", func() {
 					RcloneConfig:                   &rcloneSecret.Name,
 				}
 			})
+			When("Backup mode is specified", func() {
+				BeforeEach(func() {
+					mode := volsyncv1alpha1.RcloneModeBackup
+					retention := int32(3)
+					rs.Spec.Rclone.RcloneMode = &mode
+					rs.Spec.Rclone.RcloneBackupRetention = &retention
+				})
+				It("is passed to the mover", func() {
+					Eventually(func() error {
+						return k8sClient.Get(ctx, utils.NameFor(job), job)
+					}, maxWait, interval).Should(Succeed())
+					Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElements(
+						corev1.EnvVar{Name: "RCLONE_MODE", Value: "Backup"},
+						corev1.EnvVar{Name: "RCLONE_BACKUP_RETENTION", Value: "3"},
+					))
+				})
+				It("leaves a PVC named like the state volume that it didn't create", func() {
+					other := &corev1.PersistentVolumeClaim{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "volsync-rclone-bisync-state-" + rs.Name,
+							Namespace: rs.Namespace,
+						},
+						Spec: srcPVC.Spec,
+					}
+					Expect(k8sClient.Create(ctx, other)).To(Succeed())
+					Eventually(func() error {
+						return k8sClient.Get(ctx, utils.NameFor(job), job)
+					}, maxWait, interval).Should(Succeed())
+					Consistently(func() bool {
+						err := k8sClient.Get(ctx, utils.NameFor(other), other)
+						return err == nil && other.DeletionTimestamp.IsZero()
+					}, duration, interval).Should(BeTrue())
+				})
+			})
+			When("Bisync mode is specified", func() {
+				BeforeEach(func() {
+					mode := volsyncv1alpha1.RcloneModeBisync
+					resolution := volsyncv1alpha1.RcloneConflictKeepBoth
+					rs.Spec.Rclone.RcloneMode = &mode
+					rs.Spec.Rclone.BisyncConflictResolution = &resolution
+					rs.Spec.Rclone.CopyMethod = volsyncv1alpha1.CopyMethodNone
+				})
+				It("uses a persistent state volume", func() {
+					Eventually(func() error {
+						return k8sClient.Get(ctx, utils.NameFor(job), job)
+					}, maxWait, interval).Should(Succeed())
+					Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElements(
+						corev1.EnvVar{Name: "RCLONE_MODE", Value: "Bisync"},
+						corev1.EnvVar{Name: "BISYNC_CONFLICT_RESOLUTION", Value: "KeepBoth"},
+						corev1.EnvVar{Name: "BISYNC_WORKDIR", Value: "/bisync-state"},
+					))
+					state := &corev1.PersistentVolumeClaim{}
+					Expect(k8sClient.Get(ctx, types.NamespacedName{
+						Name:      "volsync-rclone-bisync-state-" + rs.Name,
+						Namespace: rs.Namespace,
+					}, state)).To(Succeed())
+					Expect(state).To(beOwnedBy(rs))
+					found := false
+					for _, v := range job.Spec.Template.Spec.Volumes {
+						if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == state.Name {
+							found = true
+						}
+					}
+					Expect(found).To(BeTrue())
+				})
+				It("removes the state volume when a different mode is used", func() {
+					state := &corev1.PersistentVolumeClaim{}
+					stateName := types.NamespacedName{
+						Name:      "volsync-rclone-bisync-state-" + rs.Name,
+						Namespace: rs.Namespace,
+					}
+					Eventually(func() error {
+						return k8sClient.Get(ctx, stateName, state)
+					}, maxWait, interval).Should(Succeed())
+					Eventually(func() error {
+						if err := k8sClient.Get(ctx, utils.NameFor(rs), rs); err != nil {
+							return err
+						}
+						mode := volsyncv1alpha1.RcloneModeSync
+						rs.Spec.Rclone.RcloneMode = &mode
+						return k8sClient.Update(ctx, rs)
+					}, maxWait, interval).Should(Succeed())
+					Eventually(func() bool {
+						err := k8sClient.Get(ctx, stateName, state)
+						// envtest has no PVC protection controller, so the
+						// finalizer remains after the deletion
+						return kerrors.IsNotFound(err) || !state.DeletionTimestamp.IsZero()
+					}, maxWait, interval).Should(BeTrue())
+				})
+				When("the copyMethod is Snapshot", func() {
+					BeforeEach(func() {
+						rs.Spec.Rclone.CopyMethod = volsyncv1alpha1.CopyMethodSnapshot
+					})
+					It("is rejected", func() {
+						Eventually(func() string {
+							_ = k8sClient.Get(ctx, utils.NameFor(rs), rs)
+							if rs.Status == nil {
+								return ""
+							}
+							cond := rs.Status.Conditions.GetCondition(volsyncv1alpha1.ConditionReconciled)
+							if cond == nil {
+								return ""
+							}
+							return cond.Message
+						}, maxWait, interval).Should(ContainSubstring("requires a copyMethod of None"))
+					})
+				})
+			})
 			When("The Job Succeeds", func() {
 				JustBeforeEach(func() {
 					Eventually(func() error {
@@ -24,7 +24,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	"github.com/operator-framework/operator-lib/status"
 	"github.com/prometheus/client_golang/prometheus"
 	cron "github.com/robfig/cron/v3"
@@ -72,7 +72,9 @@ type ReplicationDestinationReconciler struct {
 //+kubebuilder:rbac:groups=volsync.backube,resources=replicationdestinations/finalizers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=volsync.backube,resources=replicationdestinations/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
@@ -92,6 +94,13 @@ func (r *ReplicationDestinationReconciler) Reconcile(ctx context.Context, req ct
 		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	if !inst.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, inst, logger)
+	}
+	if err := updateFinalizer(ctx, r.Client, inst, inst.Spec.DeletionPolicy); err != nil {
+		logger.Error(err, "unable to update finalizer")
+		return ctrl.Result{}, err
+	}
 	// Prepare the .Status fields if necessary
 	if inst.Status == nil {
 		inst.Status = &volsyncv1alpha1.ReplicationDestinationStatus{}
@@ -117,6 +126,11 @@ func (r *ReplicationDestinationReconciler) Reconcile(ctx context.Context, req ct
 			return ctrl.Result{}, nil
 		}
 	}
+	// Keep the latestPVC provisioned from the most recent image
+	recheckLatestPVC := false
+	if err == nil {
+		recheckLatestPVC, err = reconcileLatestPVC(ctx, r.Client, inst, logger)
+	}
 	// Set reconcile status condition
 	if err == nil {
 		inst.Status.Conditions.SetCondition(
@@ -131,7 +145,7 @@ func (r *ReplicationDestinationReconciler) Reconcile(ctx context.Context, req ct
 			status.Condition{
 				Type:    volsyncv1alpha1.ConditionReconciled,
 				Status:  corev1.ConditionFalse,
-				Reason:  volsyncv1alpha1.ReconciledReasonError,
+				Reason:  reconciledReasonFor(err),
 				Message: err.Error(),
 			})
 	}
@@ -148,6 +162,9 @@ func (r *ReplicationDestinationReconciler) Reconcile(ctx context.Context, req ct
 			result.RequeueAfter = delta
 		}
 	}
+	if recheckLatestPVC && (result.RequeueAfter == 0 || result.RequeueAfter > latestPVCRecheckInterval) {
+		result.RequeueAfter = latestPVCRecheckInterval
+	}
 	return result, err
 }
 
@@ -188,8 +205,12 @@ func reconcileDestUsingCatalog(
 	var result mover.Result
 	if shouldSync && !instance.Status.Conditions.IsFalseFor(volsyncv1alpha1.ConditionSynchronizing) {
 		result, err = dataMover.Synchronize(ctx)
-		if result.Completed && result.Image != nil {
-			instance.Status.LatestImage = result.Image
+		if result.Completed {
+			// Some movers (e.g., a restic test restore) complete without
+			// producing a new image
+			if result.Image != nil {
+				instance.Status.LatestImage = result.Image
+			}
 			instance.Status.Conditions.SetCondition(
 				status.Condition{
 					Type:    volsyncv1alpha1.ConditionSynchronizing,
@@ -248,8 +269,10 @@ type rcloneDestReconciler struct {
 	destinationVolumeHandler
 	volsyncMetrics
 	rcloneConfigSecret *corev1.Secret
-	serviceAccount     *corev1.ServiceAccount
-	job                *batchv1.Job
+	// Section of the rclone configuration that describes the remote
+	rcloneConfigSection string
+	serviceAccount      *corev1.ServiceAccount
+	job                 *batchv1.Job
 }
 
 //nolint:dupl
@@ -316,14 +339,7 @@ func awaitNextSyncDestination(
 			)
 			return false, nil
 		}
-		rd.Status.Conditions.SetCondition(
-			status.Condition{
-				Type:    volsyncv1alpha1.ConditionSynchronizing,
-				Status:  corev1.ConditionTrue,
-				Reason:  volsyncv1alpha1.SynchronizingReasonSync,
-				Message: "Synchronization in-progress",
-			},
-		)
+		markSyncInProgress(&rd.Status.Conditions)
 		return true, nil
 	}
 
@@ -335,14 +351,7 @@ func awaitNextSyncDestination(
 
 	// if it's past the nextSyncTime, we should sync
 	if rd.Status.NextSyncTime.Time.Before(time.Now()) {
-		rd.Status.Conditions.SetCondition(
-			status.Condition{
-				Type:    volsyncv1alpha1.ConditionSynchronizing,
-				Status:  corev1.ConditionTrue,
-				Reason:  volsyncv1alpha1.SynchronizingReasonSync,
-				Message: "Synchronization in-progress",
-			},
-		)
+		markSyncInProgress(&rd.Status.Conditions)
 		return true, nil
 	}
 	rd.Status.Conditions.SetCondition(
@@ -513,6 +522,7 @@ func (r *rsyncDestReconciler) ensureService(l logr.Logger) (bool, error) {
 		Type:     r.Instance.Spec.Rsync.ServiceType,
 		Selector: r.serviceSelector(),
 		Port:     r.Instance.Spec.Rsync.Port,
+		Options:  r.Instance.Spec.Rsync.RsyncServiceOptions,
 	}
 	return svcDesc.Reconcile(l)
 }
@@ -520,18 +530,24 @@ func (r *rsyncDestReconciler) ensureService(l logr.Logger) (bool, error) {
 func (r *rsyncDestReconciler) publishSvcAddress(l logr.Logger) (bool, error) {
 	if r.service == nil { // no service, nothing to do
 		r.Instance.Status.Rsync.Address = nil
+		r.Instance.Status.Rsync.Addresses = nil
+		r.Instance.Status.Rsync.Port = nil
 		return true, nil
 	}
 
-	address := getServiceAddress(r.service)
-	if address == "" {
+	addresses, port, err := utils.ServiceAddresses(r.Ctx, r.Client, r.service)
+	if len(addresses) == 0 || err != nil {
 		// We don't have an address yet, try again later
 		r.Instance.Status.Rsync.Address = nil
-		return false, nil
+		r.Instance.Status.Rsync.Addresses = nil
+		r.Instance.Status.Rsync.Port = nil
+		return false, err
 	}
-	r.Instance.Status.Rsync.Address = &address
+	r.Instance.Status.Rsync.Address = &addresses[0]
+	r.Instance.Status.Rsync.Addresses = addresses
+	r.Instance.Status.Rsync.Port = &port
 
-	l.V(1).Info("Service addr published", "address", address)
+	l.V(1).Info("Service addr published", "addresses", addresses, "port", port)
 	return true, nil
 }
 
@@ -560,6 +576,9 @@ func (r *rsyncDestReconciler) ensureSecrets(l logr.Logger) (bool, error) {
 		Scheme:       r.Scheme,
 		Owner:        r.Instance,
 		NameTemplate: "volsync-rsync-dest",
+		IsSource:     false,
+		KeyType:      r.Instance.Spec.Rsync.SSHKeyType,
+		Rotation:     r.Instance.Spec.Rsync.SSHKeyRotation,
 	}
 	cont, err := keyInfo.Reconcile(l)
 	if !cont || err != nil {
@@ -573,6 +592,18 @@ func (r *rsyncDestReconciler) ensureSecrets(l logr.Logger) (bool, error) {
 }
 
 func (r *rcloneDestReconciler) ensureRcloneConfig(l logr.Logger) (bool, error) {
+	spec := r.Instance.Spec.Rclone
+	if spec.RcloneBackend != nil {
+		secret, err := ensureGeneratedRcloneConfig(r.Ctx, r.Client, r.Scheme, l, r.Instance,
+			"volsync-rclone-dst-config-"+r.Instance.Name, spec.RcloneBackend)
+		if secret == nil || err != nil {
+			return false, err
+		}
+		r.rcloneConfigSecret = secret
+		r.rcloneConfigSection = rcloneGeneratedSection
+		return true, nil
+	}
+
 	// If user provided "rclone-secret", use those
 	r.rcloneConfigSecret = &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -585,6 +616,7 @@ func (r *rcloneDestReconciler) ensureRcloneConfig(l logr.Logger) (bool, error) {
 		l.Error(err, "Rclone config secret does not contain the proper fields")
 		return false, err
 	}
+	r.rcloneConfigSection = *spec.RcloneConfigSection
 	l.Info("RcloneConfig reconciled")
 
 	return true, nil
@@ -597,7 +629,8 @@ func (r *rsyncDestReconciler) ensureServiceAccount(l logr.Logger) (bool, error)
 			Namespace: r.Instance.Namespace,
 		},
 	}
-	saDesc := utils.NewSAHandler(r.Ctx, r.Client, r.Instance, r.serviceAccount)
+	saDesc := utils.NewSAHandler(r.Ctx, r.Client, r.Instance, r.serviceAccount,
+		r.Instance.Spec.MoverServiceAccount)
 	return saDesc.Reconcile(l)
 }
 
@@ -608,7 +641,8 @@ func (r *rcloneDestReconciler) ensureServiceAccount(l logr.Logger) (bool, error)
 			Namespace: r.Instance.Namespace,
 		},
 	}
-	saDesc := utils.NewSAHandler(r.Ctx, r.Client, r.Instance, r.serviceAccount)
+	saDesc := utils.NewSAHandler(r.Ctx, r.Client, r.Instance, r.serviceAccount,
+		r.Instance.Spec.MoverServiceAccount)
 	return saDesc.Reconcile(l)
 }
 
@@ -651,6 +685,10 @@ func (r *rsyncDestReconciler) ensureJob(l logr.Logger) (bool, error) {
 			r.job.Spec.Template.Spec.Containers = []corev1.Container{{}}
 		}
 		r.job.Spec.Template.Spec.Containers[0].Name = "rsync"
+		r.job.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{
+			{Name: "CONNECTION_TIMEOUT", Value: timeoutEnv(r.Instance.Spec.Rsync.ConnectionTimeoutSeconds)},
+			{Name: "TRANSFER_TIMEOUT", Value: timeoutEnv(r.Instance.Spec.Rsync.TransferTimeoutSeconds)},
+		}
 		r.job.Spec.Template.Spec.Containers[0].Command = []string{"/bin/bash", "-c", "/destination.sh"}
 		r.job.Spec.Template.Spec.Containers[0].Image = RsyncContainerImage
 		runAsUser := int64(0)
@@ -664,9 +702,9 @@ func (r *rsyncDestReconciler) ensureJob(l logr.Logger) (bool, error) {
 			RunAsUser: &runAsUser,
 		}
 		r.job.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
-			{Name: dataVolumeName, MountPath: mountPath},
 			{Name: "keys", MountPath: "/keys"},
 		}
+		utils.AttachDataVolume(&r.job.Spec.Template.Spec.Containers[0], dataVolumeName, r.PVC, mountPath)
 		r.job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
 		r.job.Spec.Template.Spec.ServiceAccountName = r.serviceAccount.Name
 		secretMode := int32(0600)
@@ -687,6 +725,27 @@ func (r *rsyncDestReconciler) ensureJob(l logr.Logger) (bool, error) {
 		return nil
 	})
 
+	// If the listener timed out, restart it so it's ready for the source's
+	// next attempt
+	if r.job.Status.Failed > 0 {
+		timeout, terr := getRsyncTimeout(r.Ctx, r.Client, r.job)
+		if terr != nil {
+			logger.Error(terr, "unable to check for a timeout")
+		} else if timeout != "" {
+			logger.Info("deleting job -- timed out", "reason", timeout)
+			r.Instance.Status.Conditions.SetCondition(
+				status.Condition{
+					Type:    volsyncv1alpha1.ConditionSynchronizing,
+					Status:  corev1.ConditionTrue,
+					Reason:  volsyncv1alpha1.SynchronizingReasonTimedOut,
+					Message: "Listener restarted: " + timeout,
+				},
+			)
+			err = r.Client.Delete(r.Ctx, r.job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			return false, err
+		}
+	}
+
 	// If Job had failed, delete it so it can be recreated
 	if r.job.Status.Failed >= *r.job.Spec.BackoffLimit {
 		logger.Info("deleting job -- backoff limit reached")
@@ -700,6 +759,20 @@ func (r *rsyncDestReconciler) ensureJob(l logr.Logger) (bool, error) {
 		logger.V(1).Info("Job reconciled", "operation", op)
 	}
 
+	// Until the transfer completes, we're waiting on the source. A previous
+	// timeout remains visible until then.
+	cond := r.Instance.Status.Conditions.GetCondition(volsyncv1alpha1.ConditionSynchronizing)
+	if r.job.Status.Succeeded == 0 && (cond == nil || cond.Reason != volsyncv1alpha1.SynchronizingReasonTimedOut) {
+		r.Instance.Status.Conditions.SetCondition(
+			status.Condition{
+				Type:    volsyncv1alpha1.ConditionSynchronizing,
+				Status:  corev1.ConditionTrue,
+				Reason:  volsyncv1alpha1.SynchronizingReasonWaitingForSource,
+				Message: "Waiting for the source to connect",
+			},
+		)
+	}
+
 	// We only continue reconciling if the rsync job has completed
 	return r.job.Status.Succeeded == 1, nil
 }
@@ -741,7 +814,7 @@ func (r *rcloneDestReconciler) ensureJob(l logr.Logger) (bool, error) {
 			{Name: "RCLONE_DEST_PATH", Value: *r.Instance.Spec.Rclone.RcloneDestPath},
 			{Name: "DIRECTION", Value: "destination"},
 			{Name: "MOUNT_PATH", Value: mountPath},
-			{Name: "RCLONE_CONFIG_SECTION", Value: *r.Instance.Spec.Rclone.RcloneConfigSection},
+			{Name: "RCLONE_CONFIG_SECTION", Value: r.rcloneConfigSection},
 		}
 		r.job.Spec.Template.Spec.Containers[0].Command = []string{"/bin/bash", "-c", "./active.sh"}
 		r.job.Spec.Template.Spec.Containers[0].Image = RcloneContainerImage
@@ -750,9 +823,9 @@ func (r *rcloneDestReconciler) ensureJob(l logr.Logger) (bool, error) {
 			RunAsUser: &runAsUser,
 		}
 		r.job.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
-			{Name: dataVolumeName, MountPath: mountPath},
 			{Name: rcloneSecret, MountPath: "/rclone-config/"},
 		}
+		utils.AttachDataVolume(&r.job.Spec.Template.Spec.Containers[0], dataVolumeName, r.PVC, mountPath)
 		r.job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
 		r.job.Spec.Template.Spec.ServiceAccountName = r.serviceAccount.Name
 		secretMode := int32(0600)
@@ -826,6 +899,18 @@ func (r *rcloneDestReconciler) cleanupJob(l logr.Logger) (bool, error) {
 	if r.job.Status.Succeeded >= 1 {
 		logger.Info("Job succeeded", "Job", r.job.Spec)
 
+		permErr, err := getRclonePermissionsError(r.Ctx, r.Client, r.job)
+		if err != nil {
+			logger.Error(err, "unable to check permissions restore result")
+			return false, err
+		}
+		if permErr != "" {
+			logger.Info("unable to restore permissions", "reason", permErr)
+		}
+		r.Instance.Status.Rclone = &volsyncv1alpha1.ReplicationDestinationRcloneStatus{
+			PermissionsRestoreError: permErr,
+		}
+
 		if err := r.Client.Delete(r.Ctx, r.job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
 			logger.Error(err, "unable to delete job")
 			return false, err
@@ -836,26 +921,11 @@ func (r *rcloneDestReconciler) cleanupJob(l logr.Logger) (bool, error) {
 }
 
 func (r *rcloneDestReconciler) validateRcloneSpec(l logr.Logger) (bool, error) {
-	l.V(1).Info("Initiate RcloneSpec validation")
-	rclone := r.destinationVolumeHandler.Instance.Spec.Rclone
-	if len(*rclone.RcloneConfig) == 0 {
-		l.V(1).Info("couldnt validate rcloneconfig")
-		err := errors.New("Unable to get Rclone config secret name")
-		l.V(1).Info("Unable to get Rclone config secret name")
-		return false, err
-	}
-	if len(*r.Instance.Spec.Rclone.RcloneConfigSection) == 0 {
-		err := errors.New("Unable to get Rclone config section name")
-		l.V(1).Info("Unable to get Rclone config section name")
-
-		return false, err
-	}
-	if len(*r.Instance.Spec.Rclone.RcloneDestPath) == 0 {
-		err := errors.New("Unable to get Rclone destination name")
-		l.V(1).Info("Unable to get Rclone destination name")
-
+	spec := r.Instance.Spec.Rclone
+	if err := validateRcloneSpec(spec.RcloneConfig, spec.RcloneConfigSection, spec.RcloneDestPath,
+		spec.RcloneBackend); err != nil {
+		l.V(1).Info("Rclone spec validation failed", "reason", err.Error())
 		return false, err
 	}
-	l.V(1).Info("Rclone config validation complete.")
 	return true, nil
 }
@@ -5,7 +5,7 @@ import (
 	"context"
 	"time"
 
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/operator-framework/operator-lib/status"
@@ -50,6 +50,19 @@ var _ = Describe("Destination trigger", func() {
 			Expect(e).To(BeNil())
 			Expect(rd.Status.NextSyncTime).To(Not(BeNil()))
 		})
+		It("keeps a timeout that was reported during the synchronization", func() {
+			rd.Status.LastSyncTime = nil
+			rd.Status.Conditions.SetCondition(status.Condition{
+				Type:   volsyncv1alpha1.ConditionSynchronizing,
+				Status: corev1.ConditionTrue,
+				Reason: volsyncv1alpha1.SynchronizingReasonTimedOut,
+			})
+			b, e := awaitNextSyncDestination(rd, metrics, logger)
+			Expect(b).To(BeTrue())
+			Expect(e).To(BeNil())
+			cond := rd.Status.Conditions.GetCondition(volsyncv1alpha1.ConditionSynchronizing)
+			Expect(cond.Reason).To(Equal(volsyncv1alpha1.SynchronizingReasonTimedOut))
+		})
 		It("if synced long ago, sync now", func() {
 			when := metav1.Time{Time: time.Now().Add(-5 * time.Hour)}
 			rd.Status.LastSyncTime = &when
@@ -431,6 +444,124 @@ var _ = Describe("ReplicationDestination", func() {
 				Expect(secret).NotTo(beOwnedBy(rd))
 			})
 		})
+
+		It("waits for the source", func() {
+			Eventually(func() bool {
+				_ = k8sClient.Get(ctx, utils.NameFor(rd), rd)
+				if rd.Status == nil {
+					return false
+				}
+				cond := rd.Status.Conditions.GetCondition(volsyncv1alpha1.ConditionSynchronizing)
+				return cond != nil && cond.Reason == volsyncv1alpha1.SynchronizingReasonWaitingForSource
+			}, maxWait, interval).Should(BeTrue())
+		})
+
+		Context("when volumeMode is Block", func() {
+			BeforeEach(func() {
+				block := v1.PersistentVolumeBlock
+				rd.Spec.Rsync.VolumeMode = &block
+			})
+			It("the volume is attached as a device", func() {
+				job := &batchv1.Job{}
+				Eventually(func() error {
+					return k8sClient.Get(ctx, types.NamespacedName{Name: "volsync-rsync-dest-" + rd.Name, Namespace: rd.Namespace}, job)
+				}, maxWait, interval).Should(Succeed())
+				container := job.Spec.Template.Spec.Containers[0]
+				Expect(container.VolumeDevices).To(ConsistOf(
+					v1.VolumeDevice{Name: dataVolumeName, DevicePath: utils.BlockDevicePath}))
+				for _, m := range container.VolumeMounts {
+					Expect(m.Name).NotTo(Equal(dataVolumeName))
+				}
+				pvc := &v1.PersistentVolumeClaim{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "volsync-dest-" + rd.Name, Namespace: rd.Namespace},
+					pvc)).To(Succeed())
+				Expect(*pvc.Spec.VolumeMode).To(Equal(v1.PersistentVolumeBlock))
+			})
+		})
+
+		Context("when timeouts are specified", func() {
+			BeforeEach(func() {
+				connTimeout := int64(600)
+				rd.Spec.Rsync.ConnectionTimeoutSeconds = &connTimeout
+			})
+			It("they are passed to the mover", func() {
+				job := &batchv1.Job{}
+				Eventually(func() error {
+					return k8sClient.Get(ctx, types.NamespacedName{Name: "volsync-rsync-dest-" + rd.Name, Namespace: rd.Namespace}, job)
+				}, maxWait, interval).Should(Succeed())
+				Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElements(
+					v1.EnvVar{Name: "CONNECTION_TIMEOUT", Value: "600"},
+					v1.EnvVar{Name: "TRANSFER_TIMEOUT", Value: "0"},
+				))
+			})
+			// Fails the listener with a connection timeout, and returns the
+			// Job that timed out
+			timeOutListener := func() *batchv1.Job {
+				job := &batchv1.Job{}
+				Eventually(func() error {
+					return k8sClient.Get(ctx, types.NamespacedName{Name: "volsync-rsync-dest-" + rd.Name, Namespace: rd.Namespace}, job)
+				}, maxWait, interval).Should(Succeed())
+				pod := &v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      job.Name + "-x",
+						Namespace: job.Namespace,
+						Labels:    map[string]string{"job-name": job.Name},
+					},
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{{Name: "rsync", Image: "img"}},
+					},
+				}
+				Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+				pod.Status.Phase = v1.PodFailed
+				pod.Status.ContainerStatuses = []v1.ContainerStatus{{
+					Name: "rsync",
+					State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+						ExitCode: 1,
+						Message:  "timeout: no connection from the source within 600s",
+					}},
+				}}
+				Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+				job.Status.Failed = 1
+				Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+				return job
+			}
+			getSyncReason := func() string {
+				_ = k8sClient.Get(ctx, utils.NameFor(rd), rd)
+				cond := rd.Status.Conditions.GetCondition(volsyncv1alpha1.ConditionSynchronizing)
+				if cond == nil {
+					return ""
+				}
+				return string(cond.Reason)
+			}
+			isRestarted := func(job *batchv1.Job) func() bool {
+				return func() bool {
+					newJob := &batchv1.Job{}
+					err := k8sClient.Get(ctx, utils.NameFor(job), newJob)
+					return err == nil && newJob.UID != job.UID
+				}
+			}
+			It("restarts the listener when it times out", func() {
+				job := timeOutListener()
+				Eventually(getSyncReason, maxWait, interval).Should(
+					Equal(string(volsyncv1alpha1.SynchronizingReasonTimedOut)))
+				// A new listener is started
+				Eventually(isRestarted(job), maxWait, interval).Should(BeTrue())
+			})
+			When("a schedule is specified", func() {
+				BeforeEach(func() {
+					schedule := "0 0 1 1 *"
+					rd.Spec.Trigger = &volsyncv1alpha1.ReplicationDestinationTriggerSpec{
+						Schedule: &schedule,
+					}
+				})
+				It("the timeout remains visible until the synchronization completes", func() {
+					job := timeOutListener()
+					Eventually(isRestarted(job), maxWait, interval).Should(BeTrue())
+					Consistently(getSyncReason, duration, interval).Should(
+						Equal(string(volsyncv1alpha1.SynchronizingReasonTimedOut)))
+				})
+			})
+		})
 	})
 
 	Context("after sync is complete", func() {
@@ -485,9 +616,11 @@ var _ = Describe("ReplicationDestination", func() {
 					return snapList.Items
 				}, maxWait, interval).Should(Not(BeEmpty()))
 				snap := snapList.Items[0]
+				ready := true
 				foo := "foo"
 				snap.Status = &snapv1.VolumeSnapshotStatus{
 					BoundVolumeSnapshotContentName: &foo,
+					ReadyToUse:                     &ready,
 				}
 				Expect(k8sClient.Status().Update(ctx, &snap)).To(Succeed())
 				By("seeing the now-bound snap in the LatestImage field")
@@ -501,5 +634,42 @@ var _ = Describe("ReplicationDestination", func() {
 				Expect(li.Name).To(Not(Equal("")))
 			})
 		})
+		Context("with a CopyMethod of Clone", func() {
+			BeforeEach(func() {
+				rd.Spec.Rsync.CopyMethod = volsyncv1alpha1.CopyMethodClone
+			})
+			It("a clone should be the latestImage", func() {
+				By("once the clone is created, force it to be bound")
+				pvc := &v1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "volsync-dest-" + rd.Name,
+						Namespace: rd.Namespace,
+					},
+				}
+				clone := &v1.PersistentVolumeClaim{}
+				Eventually(func() error {
+					if err := k8sClient.Get(ctx, utils.NameFor(pvc), pvc); err != nil {
+						return err
+					}
+					cloneName := types.NamespacedName{
+						Name:      pvc.Annotations[cloneAnnotation],
+						Namespace: rd.Namespace,
+					}
+					return k8sClient.Get(ctx, cloneName, clone)
+				}, maxWait, interval).Should(Succeed())
+				Expect(clone.Spec.DataSource.Name).To(Equal(pvc.Name))
+				clone.Status.Phase = v1.ClaimBound
+				Expect(k8sClient.Status().Update(ctx, clone)).To(Succeed())
+				By("seeing the now-bound clone in the LatestImage field")
+				Eventually(func() *v1.TypedLocalObjectReference {
+					_ = k8sClient.Get(ctx, utils.NameFor(rd), rd)
+					return rd.Status.LatestImage
+				}, maxWait, interval).Should(Not(BeNil()))
+				li := rd.Status.LatestImage
+				Expect(li.Kind).To(Equal("PersistentVolumeClaim"))
+				Expect(*li.APIGroup).To(Equal(""))
+				Expect(li.Name).To(Equal(clone.Name))
+			})
+		})
 	})
 })
@@ -25,7 +25,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	"github.com/operator-framework/operator-lib/status"
 	"github.com/prometheus/client_golang/prometheus"
 	cron "github.com/robfig/cron/v3"
@@ -33,6 +33,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -42,6 +43,7 @@ import (
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	"github.com/backube/volsync/controllers/mover"
 	"github.com/backube/volsync/controllers/utils"
+	"github.com/backube/volsync/controllers/volumehandler"
 )
 
 const (
@@ -62,7 +64,9 @@ type ReplicationSourceReconciler struct {
 //+kubebuilder:rbac:groups=volsync.backube,resources=replicationsources/finalizers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=volsync.backube,resources=replicationsources/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
@@ -70,6 +74,8 @@ type ReplicationSourceReconciler struct {
 //+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,resourceNames=volsync-mover,verbs=use
 //+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses;csidrivers,verbs=get;list;watch
 
 //nolint:funlen
 func (r *ReplicationSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -81,6 +87,13 @@ func (r *ReplicationSourceReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	if !inst.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, inst, logger)
+	}
+	if err := updateFinalizer(ctx, r.Client, inst, inst.Spec.DeletionPolicy); err != nil {
+		logger.Error(err, "unable to update finalizer")
+		return ctrl.Result{}, err
+	}
 
 	if inst.Status == nil {
 		inst.Status = &volsyncv1alpha1.ReplicationSourceStatus{}
@@ -120,7 +133,7 @@ func (r *ReplicationSourceReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			status.Condition{
 				Type:    volsyncv1alpha1.ConditionReconciled,
 				Status:  corev1.ConditionFalse,
-				Reason:  volsyncv1alpha1.ReconciledReasonError,
+				Reason:  reconciledReasonFor(err),
 				Message: err.Error(),
 			})
 	}
@@ -143,6 +156,21 @@ func (r *ReplicationSourceReconciler) Reconcile(ctx context.Context, req ctrl.Re
 
 var errNoMoverFound = fmt.Errorf("no matching data mover was found")
 
+// reconciledReasonFor returns the reason of the Reconciled condition that
+// describes a failed reconcile
+func reconciledReasonFor(err error) status.ConditionReason {
+	switch {
+	case errors.Is(err, mover.ErrDeadlineExceeded):
+		return volsyncv1alpha1.ReconciledReasonDeadlineExceeded
+	case errors.Is(err, volumehandler.ErrSnapshotFailed):
+		return volsyncv1alpha1.ReconciledReasonSnapshotFailed
+	case errors.Is(err, volumehandler.ErrSnapshotTimeout):
+		return volsyncv1alpha1.ReconciledReasonSnapshotTimeout
+	default:
+		return volsyncv1alpha1.ReconciledReasonError
+	}
+}
+
 //nolint:funlen
 func reconcileSrcUsingCatalog(
 	ctx context.Context,
@@ -297,14 +325,7 @@ func awaitNextSyncSource(
 			)
 			return false, nil
 		}
-		rs.Status.Conditions.SetCondition(
-			status.Condition{
-				Type:    volsyncv1alpha1.ConditionSynchronizing,
-				Status:  corev1.ConditionTrue,
-				Reason:  volsyncv1alpha1.SynchronizingReasonSync,
-				Message: "Synchronization in-progress",
-			},
-		)
+		markSyncInProgress(&rs.Status.Conditions)
 		return true, nil
 	}
 
@@ -316,14 +337,7 @@ func awaitNextSyncSource(
 
 	// if it's past the nextSyncTime, we should sync
 	if rs.Status.NextSyncTime.Time.Before(time.Now()) {
-		rs.Status.Conditions.SetCondition(
-			status.Condition{
-				Type:    volsyncv1alpha1.ConditionSynchronizing,
-				Status:  corev1.ConditionTrue,
-				Reason:  volsyncv1alpha1.SynchronizingReasonSync,
-				Message: "Synchronization in-progress",
-			},
-		)
+		markSyncInProgress(&rs.Status.Conditions)
 		return true, nil
 	}
 	rs.Status.Conditions.SetCondition(
@@ -388,8 +402,12 @@ type rcloneSrcReconciler struct {
 	sourceVolumeHandler
 	volsyncMetrics
 	rcloneConfigSecret *corev1.Secret
-	serviceAccount     *corev1.ServiceAccount
-	job                *batchv1.Job
+	// Section of the rclone configuration that describes the remote
+	rcloneConfigSection string
+	// Volume that holds the state of a bidirectional sync
+	bisyncState    *corev1.PersistentVolumeClaim
+	serviceAccount *corev1.ServiceAccount
+	job            *batchv1.Job
 }
 
 //nolint:dupl
@@ -427,11 +445,13 @@ func RunRsyncSrcReconciler(
 	}
 
 	_, err := utils.ReconcileBatch(l,
+		r.validateCopyMethod,
 		awaitNextSync,
 		r.EnsurePVC,
 		r.ensureService,
 		r.publishSvcAddress,
 		r.ensureKeys,
+		r.validateProxy,
 		r.ensureServiceAccount,
 		r.ensureJob,
 		r.cleanupJob,
@@ -470,9 +490,11 @@ func RunRcloneSrcReconciler(
 	}
 
 	_, err := utils.ReconcileBatch(l,
+		r.validateCopyMethod,
 		awaitNextSync,
 		r.validateRcloneSpec,
 		r.EnsurePVC,
+		r.ensureBisyncState,
 		r.ensureServiceAccount,
 		r.ensureRcloneConfig,
 		r.ensureJob,
@@ -519,7 +541,25 @@ func (r *rcloneSrcReconciler) ensureJob(l logr.Logger) (bool, error) {
 			{Name: "RCLONE_DEST_PATH", Value: *r.Instance.Spec.Rclone.RcloneDestPath},
 			{Name: "DIRECTION", Value: "source"},
 			{Name: "MOUNT_PATH", Value: mountPath},
-			{Name: "RCLONE_CONFIG_SECTION", Value: *r.Instance.Spec.Rclone.RcloneConfigSection},
+			{Name: "RCLONE_CONFIG_SECTION", Value: r.rcloneConfigSection},
+		}
+		if r.Instance.Spec.Rclone.RcloneMode != nil {
+			r.job.Spec.Template.Spec.Containers[0].Env = append(r.job.Spec.Template.Spec.Containers[0].Env,
+				corev1.EnvVar{Name: "RCLONE_MODE", Value: string(*r.Instance.Spec.Rclone.RcloneMode)})
+		}
+		if r.Instance.Spec.Rclone.RcloneBackupRetention != nil {
+			r.job.Spec.Template.Spec.Containers[0].Env = append(r.job.Spec.Template.Spec.Containers[0].Env,
+				corev1.EnvVar{Name: "RCLONE_BACKUP_RETENTION",
+					Value: strconv.Itoa(int(*r.Instance.Spec.Rclone.RcloneBackupRetention))})
+		}
+		if r.bisyncState != nil {
+			resolution := volsyncv1alpha1.RcloneConflictNewerWins
+			if r.Instance.Spec.Rclone.BisyncConflictResolution != nil {
+				resolution = *r.Instance.Spec.Rclone.BisyncConflictResolution
+			}
+			r.job.Spec.Template.Spec.Containers[0].Env = append(r.job.Spec.Template.Spec.Containers[0].Env,
+				corev1.EnvVar{Name: "BISYNC_WORKDIR", Value: rcloneBisyncStateMountPath},
+				corev1.EnvVar{Name: "BISYNC_CONFLICT_RESOLUTION", Value: string(resolution)})
 		}
 		r.job.Spec.Template.Spec.Containers[0].Command = []string{"/bin/bash", "-c", "./active.sh"}
 		r.job.Spec.Template.Spec.Containers[0].Image = RcloneContainerImage
@@ -528,9 +568,9 @@ func (r *rcloneSrcReconciler) ensureJob(l logr.Logger) (bool, error) {
 			RunAsUser: &runAsUser,
 		}
 		r.job.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
-			{Name: dataVolumeName, MountPath: mountPath},
 			{Name: rcloneSecret, MountPath: "/rclone-config/"},
 		}
+		utils.AttachDataVolume(&r.job.Spec.Template.Spec.Containers[0], dataVolumeName, r.PVC, mountPath)
 		r.job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
 		r.job.Spec.Template.Spec.ServiceAccountName = r.serviceAccount.Name
 		secretMode := int32(0600)
@@ -547,6 +587,17 @@ func (r *rcloneSrcReconciler) ensureJob(l logr.Logger) (bool, error) {
 				}},
 			},
 		}
+		if r.bisyncState != nil {
+			r.job.Spec.Template.Spec.Containers[0].VolumeMounts = append(
+				r.job.Spec.Template.Spec.Containers[0].VolumeMounts,
+				corev1.VolumeMount{Name: rcloneBisyncStateVolume, MountPath: rcloneBisyncStateMountPath})
+			r.job.Spec.Template.Spec.Volumes = append(r.job.Spec.Template.Spec.Volumes,
+				corev1.Volume{Name: rcloneBisyncStateVolume, VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: r.bisyncState.Name,
+					}},
+				})
+		}
 		logger.V(1).Info("Job has PVC", "PVC", r.PVC, "DS", r.PVC.Spec.DataSource)
 		return nil
 	})
@@ -596,6 +647,7 @@ func (r *rsyncSrcReconciler) ensureService(l logr.Logger) (bool, error) {
 		Type:     r.Instance.Spec.Rsync.ServiceType,
 		Selector: r.serviceSelector(),
 		Port:     r.Instance.Spec.Rsync.Port,
+		Options:  r.Instance.Spec.Rsync.RsyncServiceOptions,
 	}
 	return svcDesc.Reconcile(l)
 }
@@ -603,18 +655,33 @@ func (r *rsyncSrcReconciler) ensureService(l logr.Logger) (bool, error) {
 func (r *rsyncSrcReconciler) publishSvcAddress(l logr.Logger) (bool, error) {
 	if r.service == nil { // no service, nothing to do
 		r.Instance.Status.Rsync.Address = nil
+		r.Instance.Status.Rsync.Addresses = nil
+		r.Instance.Status.Rsync.Port = nil
 		return true, nil
 	}
 
-	address := getServiceAddress(r.service)
-	if address == "" {
+	addresses, port, err := utils.ServiceAddresses(r.Ctx, r.Client, r.service)
+	if len(addresses) == 0 || err != nil {
 		// We don't have an address yet, try again later
 		r.Instance.Status.Rsync.Address = nil
-		return false, nil
+		r.Instance.Status.Rsync.Addresses = nil
+		r.Instance.Status.Rsync.Port = nil
+		return false, err
 	}
-	r.Instance.Status.Rsync.Address = &address
+	r.Instance.Status.Rsync.Address = &addresses[0]
+	r.Instance.Status.Rsync.Addresses = addresses
+	r.Instance.Status.Rsync.Port = &port
+
+	l.V(1).Info("Service addr published", "addresses", addresses, "port", port)
+	return true, nil
+}
 
-	l.V(1).Info("Service addr published", "address", address)
+func (r *rsyncSrcReconciler) validateProxy(l logr.Logger) (bool, error) {
+	err := validateRsyncProxy(r.Ctx, r.Client, l, r.Instance.Namespace, r.Instance.Spec.Rsync.Proxy)
+	if err != nil {
+		l.Error(err, "invalid proxy configuration")
+		return false, err
+	}
 	return true, nil
 }
 
@@ -643,6 +710,9 @@ func (r *rsyncSrcReconciler) ensureKeys(l logr.Logger) (bool, error) {
 		Scheme:       r.Scheme,
 		Owner:        r.Instance,
 		NameTemplate: "volsync-rsync-src",
+		IsSource:     true,
+		KeyType:      r.Instance.Spec.Rsync.SSHKeyType,
+		Rotation:     r.Instance.Spec.Rsync.SSHKeyRotation,
 	}
 	cont, err := keyInfo.Reconcile(l)
 	if !cont || err != nil {
@@ -656,6 +726,18 @@ func (r *rsyncSrcReconciler) ensureKeys(l logr.Logger) (bool, error) {
 }
 
 func (r *rcloneSrcReconciler) ensureRcloneConfig(l logr.Logger) (bool, error) {
+	spec := r.Instance.Spec.Rclone
+	if spec.RcloneBackend != nil {
+		secret, err := ensureGeneratedRcloneConfig(r.Ctx, r.Client, r.Scheme, l, r.Instance,
+			"volsync-rclone-src-config-"+r.Instance.Name, spec.RcloneBackend)
+		if secret == nil || err != nil {
+			return false, err
+		}
+		r.rcloneConfigSecret = secret
+		r.rcloneConfigSection = rcloneGeneratedSection
+		return true, nil
+	}
+
 	// If user provided "rclone-secret", use those
 
 	r.rcloneConfigSecret = &corev1.Secret{
@@ -669,6 +751,7 @@ func (r *rcloneSrcReconciler) ensureRcloneConfig(l logr.Logger) (bool, error) {
 		l.Error(err, "Rclone config secret does not contain the proper fields")
 		return false, err
 	}
+	r.rcloneConfigSection = *spec.RcloneConfigSection
 	return true, nil
 }
 
@@ -679,7 +762,8 @@ func (r *rsyncSrcReconciler) ensureServiceAccount(l logr.Logger) (bool, error) {
 			Namespace: r.Instance.Namespace,
 		},
 	}
-	saDesc := utils.NewSAHandler(r.Ctx, r.Client, r.Instance, r.serviceAccount)
+	saDesc := utils.NewSAHandler(r.Ctx, r.Client, r.Instance, r.serviceAccount,
+		r.Instance.Spec.MoverServiceAccount)
 	return saDesc.Reconcile(l)
 }
 
@@ -690,7 +774,8 @@ func (r *rcloneSrcReconciler) ensureServiceAccount(l logr.Logger) (bool, error)
 			Namespace: r.Instance.Namespace,
 		},
 	}
-	saDesc := utils.NewSAHandler(r.Ctx, r.Client, r.Instance, r.serviceAccount)
+	saDesc := utils.NewSAHandler(r.Ctx, r.Client, r.Instance, r.serviceAccount,
+		r.Instance.Spec.MoverServiceAccount)
 	return saDesc.Reconcile(l)
 }
 
@@ -718,6 +803,7 @@ func (r *rsyncSrcReconciler) ensureJob(l logr.Logger) (bool, error) {
 		}
 		backoffLimit := int32(2)
 		r.job.Spec.BackoffLimit = &backoffLimit
+		r.job.Spec.ActiveDeadlineSeconds = r.Instance.Spec.Rsync.SyncTimeoutSeconds
 		if r.Instance.Spec.Paused {
 			parallelism := int32(0)
 			r.job.Spec.Parallelism = &parallelism
@@ -742,6 +828,12 @@ func (r *rsyncSrcReconciler) ensureJob(l logr.Logger) (bool, error) {
 		} else if r.Instance.Spec.Rsync.Address == nil {
 			r.job.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{}
 		}
+		r.job.Spec.Template.Spec.Containers[0].Env = append(r.job.Spec.Template.Spec.Containers[0].Env,
+			rsyncProxyEnv(r.Instance.Spec.Rsync.Proxy)...)
+		if r.Instance.Spec.Rsync.Parallelism != nil {
+			r.job.Spec.Template.Spec.Containers[0].Env = append(r.job.Spec.Template.Spec.Containers[0].Env,
+				corev1.EnvVar{Name: "PARALLELISM", Value: strconv.Itoa(int(*r.Instance.Spec.Rsync.Parallelism))})
+		}
 		r.job.Spec.Template.Spec.Containers[0].Command = []string{"/bin/bash", "-c", "/source.sh"}
 		r.job.Spec.Template.Spec.Containers[0].Image = RsyncContainerImage
 		runAsUser := int64(0)
@@ -755,9 +847,9 @@ func (r *rsyncSrcReconciler) ensureJob(l logr.Logger) (bool, error) {
 			RunAsUser: &runAsUser,
 		}
 		r.job.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
-			{Name: dataVolumeName, MountPath: mountPath},
 			{Name: "keys", MountPath: "/keys"},
 		}
+		utils.AttachDataVolume(&r.job.Spec.Template.Spec.Containers[0], dataVolumeName, r.PVC, mountPath)
 		r.job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
 		r.job.Spec.Template.Spec.ServiceAccountName = r.serviceAccount.Name
 		secretMode := int32(0600)
@@ -774,10 +866,33 @@ func (r *rsyncSrcReconciler) ensureJob(l logr.Logger) (bool, error) {
 				}},
 			},
 		}
+		proxyVolumes, proxyMounts := rsyncProxyVolume(r.Instance.Spec.Rsync.Proxy)
+		r.job.Spec.Template.Spec.Volumes = append(r.job.Spec.Template.Spec.Volumes, proxyVolumes...)
+		r.job.Spec.Template.Spec.Containers[0].VolumeMounts = append(
+			r.job.Spec.Template.Spec.Containers[0].VolumeMounts, proxyMounts...)
 		logger.V(1).Info("Job has PVC", "PVC", r.PVC, "DS", r.PVC.Spec.DataSource)
 		return nil
 	})
 
+	// If the Job ran past its deadline, delete it so it can be recreated, and
+	// report why this attempt was abandoned
+	if utils.JobDeadlineExceeded(r.job) {
+		logger.Info("deleting job -- deadline exceeded")
+		timeout := fmt.Sprintf("synchronization did not complete within %vs", *r.job.Spec.ActiveDeadlineSeconds)
+		r.Instance.Status.Conditions.SetCondition(
+			status.Condition{
+				Type:    volsyncv1alpha1.ConditionSynchronizing,
+				Status:  corev1.ConditionTrue,
+				Reason:  volsyncv1alpha1.SynchronizingReasonTimedOut,
+				Message: "Restarted: " + timeout,
+			},
+		)
+		if err = r.Client.Delete(r.Ctx, r.job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err == nil {
+			err = fmt.Errorf("%w: %v", mover.ErrDeadlineExceeded, timeout)
+		}
+		return false, err
+	}
+
 	// If Job had failed, delete it so it can be recreated
 	if r.job.Status.Failed >= *r.job.Spec.BackoffLimit {
 		logger.Info("deleting job -- backoff limit reached")
@@ -829,6 +944,16 @@ func (r *rcloneSrcReconciler) cleanupJob(l logr.Logger) (bool, error) {
 	}
 	// remove job
 	if r.job.Status.Succeeded >= 1 {
+		if r.bisyncState != nil {
+			conflicts, err := getRcloneBisyncConflicts(r.Ctx, r.Client, r.job)
+			if err != nil {
+				logger.Error(err, "unable to retrieve bisync conflicts")
+				return false, err
+			}
+			r.Instance.Status.Rclone = &volsyncv1alpha1.ReplicationSourceRcloneStatus{
+				LastBisyncConflicts: conflicts,
+			}
+		}
 		if err := r.Client.Delete(r.Ctx, r.job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
 			logger.Error(err, "unable to delete job")
 			return false, err
@@ -839,22 +964,89 @@ func (r *rcloneSrcReconciler) cleanupJob(l logr.Logger) (bool, error) {
 }
 
 func (r *rcloneSrcReconciler) validateRcloneSpec(l logr.Logger) (bool, error) {
-	if len(*r.Instance.Spec.Rclone.RcloneConfig) == 0 {
-		err := errors.New("Unable to get Rclone config secret name")
-		l.V(1).Info("Unable to get Rclone config secret name")
+	spec := r.Instance.Spec.Rclone
+	if err := validateRcloneSpec(spec.RcloneConfig, spec.RcloneConfigSection, spec.RcloneDestPath,
+		spec.RcloneBackend); err != nil {
+		l.V(1).Info("Rclone spec validation failed", "reason", err.Error())
 		return false, err
 	}
-	if len(*r.Instance.Spec.Rclone.RcloneConfigSection) == 0 {
-		err := errors.New("Unable to get Rclone config section name")
-		l.V(1).Info("Unable to get Rclone config section name")
-
+	if r.isBisync() && spec.CopyMethod != volsyncv1alpha1.CopyMethodNone {
+		err := errors.New("rcloneMode Bisync requires a copyMethod of None")
+		l.V(1).Info("Rclone spec validation failed", "reason", err.Error())
 		return false, err
 	}
-	if len(*r.Instance.Spec.Rclone.RcloneDestPath) == 0 {
-		err := errors.New("Unable to get Rclone destination name")
-		l.V(1).Info("Unable to get Rclone destination name")
+	return true, nil
+}
 
+func (r *rcloneSrcReconciler) isBisync() bool {
+	mode := r.Instance.Spec.Rclone.RcloneMode
+	return mode != nil && *mode == volsyncv1alpha1.RcloneModeBisync
+}
+
+// ensureBisyncState maintains the volume that holds the listings of the
+// previous run of a bidirectional sync. The volume is removed when a different
+// mode is used, since its listings would be stale if Bisync were used again.
+func (r *rcloneSrcReconciler) ensureBisyncState(l logr.Logger) (bool, error) {
+	state := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "volsync-rclone-bisync-state-" + r.Instance.Name,
+			Namespace: r.Instance.Namespace,
+		},
+	}
+	logger := l.WithValues("PVC", utils.NameFor(state))
+	if !r.isBisync() {
+		r.bisyncState = nil
+		// Only a state volume that this source created is removed
+		if err := r.Client.Get(r.Ctx, utils.NameFor(state), state); err != nil {
+			if !kerrors.IsNotFound(err) {
+				logger.Error(err, "unable to get Bisync state volume")
+			}
+			return kerrors.IsNotFound(err), client.IgnoreNotFound(err)
+		}
+		if !metav1.IsControlledBy(state, r.Instance) || !state.DeletionTimestamp.IsZero() {
+			return true, nil
+		}
+		if err := r.Client.Delete(r.Ctx, state); err != nil && !kerrors.IsNotFound(err) {
+			logger.Error(err, "unable to delete Bisync state volume")
+			return false, err
+		}
+		logger.Info("deleted Bisync state volume")
+		return true, nil
+	}
+	if utils.IsBlockVolume(r.PVC) {
+		err := errors.New("rcloneMode Bisync does not support block volumes")
+		l.V(1).Info("Rclone spec validation failed", "reason", err.Error())
+		return false, err
+	}
+	r.bisyncState = state
+	op, err := ctrlutil.CreateOrUpdate(r.Ctx, r.Client, r.bisyncState, func() error {
+		if err := ctrl.SetControllerReference(r.Instance, r.bisyncState, r.Scheme); err != nil {
+			logger.Error(err, "unable to set controller reference")
+			return err
+		}
+		if !r.bisyncState.CreationTimestamp.IsZero() {
+			// The spec of an existing PVC is immutable
+			return nil
+		}
+		capacity := resource.MustParse("1Gi")
+		if r.Instance.Spec.Rclone.BisyncStateCapacity != nil {
+			capacity = *r.Instance.Spec.Rclone.BisyncStateCapacity
+		}
+		filesystem := corev1.PersistentVolumeFilesystem
+		r.bisyncState.Spec = corev1.PersistentVolumeClaimSpec{
+			AccessModes: r.PVC.Spec.AccessModes,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: capacity},
+			},
+			StorageClassName: r.Instance.Spec.Rclone.BisyncStateStorageClassName,
+			VolumeMode:       &filesystem,
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "reconcile failed")
 		return false, err
 	}
+	logger.V(1).Info("Bisync state volume reconciled", "operation", op)
 	return true, nil
 }
@@ -9,9 +9,10 @@ import (
 
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	"github.com/backube/volsync/controllers/utils"
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/operator-framework/operator-lib/status"
 	"github.com/prometheus/client_golang/prometheus"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -48,6 +49,19 @@ var _ = Describe("Source trigger", func() {
 			Expect(e).To(BeNil())
 			Expect(rs.Status.NextSyncTime).To(Not(BeNil()))
 		})
+		It("keeps a timeout that was reported during the synchronization", func() {
+			rs.Status.LastSyncTime = nil
+			rs.Status.Conditions.SetCondition(status.Condition{
+				Type:   volsyncv1alpha1.ConditionSynchronizing,
+				Status: corev1.ConditionTrue,
+				Reason: volsyncv1alpha1.SynchronizingReasonTimedOut,
+			})
+			b, e := awaitNextSyncSource(rs, metrics, logger)
+			Expect(b).To(BeTrue())
+			Expect(e).To(BeNil())
+			cond := rs.Status.Conditions.GetCondition(volsyncv1alpha1.ConditionSynchronizing)
+			Expect(cond.Reason).To(Equal(volsyncv1alpha1.SynchronizingReasonTimedOut))
+		})
 		It("if synced long ago, sync now", func() {
 			when := metav1.Time{Time: time.Now().Add(-5 * time.Hour)}
 			rs.Status.LastSyncTime = &when
@@ -264,6 +278,29 @@ var _ = Describe("ReplicationSource", func() {
 		})
 	})
 
+	Context("when a copyMethod of Auto is specified for rsync", func() {
+		BeforeEach(func() {
+			rs.Spec.Rsync = &volsyncv1alpha1.ReplicationSourceRsyncSpec{
+				ReplicationSourceVolumeOptions: volsyncv1alpha1.ReplicationSourceVolumeOptions{
+					CopyMethod: volsyncv1alpha1.CopyMethodAuto,
+				},
+			}
+		})
+		It("is rejected", func() {
+			Eventually(func() string {
+				_ = k8sClient.Get(ctx, utils.NameFor(rs), rs)
+				if rs.Status == nil {
+					return ""
+				}
+				cond := rs.Status.Conditions.GetCondition(volsyncv1alpha1.ConditionReconciled)
+				if cond == nil {
+					return ""
+				}
+				return cond.Message
+			}, maxWait, interval).Should(ContainSubstring("copyMethod Auto is only supported"))
+		})
+	})
+
 	Context("when a copyMethod of Clone is specified", func() {
 		BeforeEach(func() {
 			rs.Spec.Rsync = &volsyncv1alpha1.ReplicationSourceRsyncSpec{
@@ -349,9 +386,11 @@ var _ = Describe("ReplicationSource", func() {
 			Eventually(func() error {
 				return k8sClient.Get(ctx, utils.NameFor(snap), snap)
 			}, maxWait, interval).Should(Succeed())
+			ready := true
 			foo := "foo"
 			snap.Status = &snapv1.VolumeSnapshotStatus{
 				BoundVolumeSnapshotContentName: &foo,
+				ReadyToUse:                     &ready,
 			}
 			Expect(k8sClient.Status().Update(ctx, snap)).To(Succeed())
 			// Continue checking
@@ -395,9 +434,11 @@ var _ = Describe("ReplicationSource", func() {
 				Eventually(func() error {
 					return k8sClient.Get(ctx, utils.NameFor(snap), snap)
 				}, maxWait, interval).Should(Succeed())
+				ready := true
 				foo := "foo2"
 				snap.Status = &snapv1.VolumeSnapshotStatus{
 					BoundVolumeSnapshotContentName: &foo,
+					ReadyToUse:                     &ready,
 				}
 				Expect(k8sClient.Status().Update(ctx, snap)).To(Succeed())
 				// Continue checking
@@ -526,6 +567,30 @@ var _ = Describe("ReplicationSource", func() {
 		})
 	})
 
+	Context("rsync: when parallelism is specified", func() {
+		BeforeEach(func() {
+			remoteAddr := "my.remote.host.com"
+			parallelism := int32(4)
+			rs.Spec.Rsync = &volsyncv1alpha1.ReplicationSourceRsyncSpec{
+				ReplicationSourceVolumeOptions: volsyncv1alpha1.ReplicationSourceVolumeOptions{
+					CopyMethod: volsyncv1alpha1.CopyMethodClone,
+				},
+				Address:     &remoteAddr,
+				Parallelism: &parallelism,
+			}
+		})
+		It("is passed to the mover", func() {
+			job := &batchv1.Job{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "volsync-rsync-src-" + rs.Name, Namespace: rs.Namespace}, job)
+			}, maxWait, interval).Should(Succeed())
+			Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(
+				corev1.EnvVar{Name: "PARALLELISM", Value: "4"}))
+			// A single Job (pod) runs all of the streams
+			Expect(*job.Spec.Parallelism).To(Equal(int32(1)))
+		})
+	})
+
 	Context("rsync: when no key is provided", func() {
 		BeforeEach(func() {
 			rs.Spec.Rsync = &volsyncv1alpha1.ReplicationSourceRsyncSpec{
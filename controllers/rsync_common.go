@@ -18,15 +18,29 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	"github.com/backube/volsync/controllers/utils"
 	"github.com/go-logr/logr"
+	"golang.org/x/crypto/ssh"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -40,6 +54,35 @@ import (
 const (
 	dataVolumeName = "data"
 	rcloneSecret   = "rclone-secret"
+	// Changing the value of this annotation on the CR causes the generated SSH
+	// keys to be rotated
+	rotateSSHKeysAnnotation = "volsync.backube/rotate-ssh-keys"
+	// Annotations on the main key Secret that track rotation
+	sshKeysCreatedAnnotation    = "volsync.backube/ssh-keys-created"
+	sshKeysRotatedForAnnotation = "volsync.backube/ssh-keys-rotated-for"
+	sshKeysGraceEndAnnotation   = "volsync.backube/ssh-keys-grace-end"
+	// How long the previous keys are accepted after a rotation if not specified
+	defaultSSHKeyGracePeriod = 24 * time.Hour
+	// Suffix of the fields in the main key Secret that hold the previous keys
+	// during the rotation grace period
+	previousKeySuffix = "-previous"
+	// Volume and Secret field holding the jump host keys
+	jumpKeysVolumeName = "jump-keys"
+	jumpKeysMountPath  = "/jump-keys"
+	jumpKeyField       = "jump"
+	jumpHostKeyField   = "jumphost.pub"
+	// Fields of the Secret holding the HTTP proxy credentials
+	proxyUsernameField = "username"
+	proxyPasswordField = "password"
+	// Prefix of the termination message of an rsync mover that timed out
+	rsyncTimeoutPrefix = "timeout: "
+	// Selects the type of load balancer that is provisioned on AWS
+	awsLoadBalancerTypeAnnotation = "service.beta.kubernetes.io/aws-load-balancer-type"
+	// Annotations on the rsync Service that record the annotation and label
+	// keys that were applied from the spec, so those that are later removed
+	// from the spec can be removed from the Service
+	managedAnnotationsAnnotation = "volsync.backube/managed-annotations"
+	managedLabelsAnnotation      = "volsync.backube/managed-labels"
 )
 
 type rsyncSvcDescription struct {
@@ -51,6 +94,7 @@ type rsyncSvcDescription struct {
 	Type     *corev1.ServiceType
 	Selector map[string]string
 	Port     *int32
+	Options  volsyncv1alpha1.RsyncServiceOptions
 }
 
 func (d *rsyncSvcDescription) Reconcile(l logr.Logger) (bool, error) {
@@ -62,17 +106,40 @@ func (d *rsyncSvcDescription) Reconcile(l logr.Logger) (bool, error) {
 			return err
 		}
 
-		if d.Service.ObjectMeta.Annotations == nil {
-			d.Service.ObjectMeta.Annotations = map[string]string{}
-		}
-		d.Service.ObjectMeta.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"] = "nlb"
-
 		if d.Type != nil {
 			d.Service.Spec.Type = *d.Type
 		} else {
 			d.Service.Spec.Type = corev1.ServiceTypeClusterIP
 		}
+
+		if d.Service.ObjectMeta.Annotations == nil {
+			d.Service.ObjectMeta.Annotations = map[string]string{}
+		}
+		if d.Service.ObjectMeta.Labels == nil {
+			d.Service.ObjectMeta.Labels = map[string]string{}
+		}
+		applyManagedKeys(d.Service.ObjectMeta.Annotations, d.Options.ServiceAnnotations,
+			d.Service.ObjectMeta.Annotations, managedAnnotationsAnnotation)
+		applyManagedKeys(d.Service.ObjectMeta.Labels, d.Options.ServiceLabels,
+			d.Service.ObjectMeta.Annotations, managedLabelsAnnotation)
+		// Default to an NLB on AWS unless the user has chosen otherwise
+		if _, ok := d.Options.ServiceAnnotations[awsLoadBalancerTypeAnnotation]; !ok {
+			if d.Service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+				d.Service.ObjectMeta.Annotations[awsLoadBalancerTypeAnnotation] = "nlb"
+			} else {
+				delete(d.Service.ObjectMeta.Annotations, awsLoadBalancerTypeAnnotation)
+			}
+		}
+
 		d.Service.Spec.Selector = d.Selector
+		if d.Service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			d.Service.Spec.LoadBalancerSourceRanges = d.Options.LoadBalancerSourceRanges
+		} else {
+			d.Service.Spec.LoadBalancerSourceRanges = nil
+		}
+		if d.Options.IPFamilyPolicy != nil {
+			d.Service.Spec.IPFamilyPolicy = d.Options.IPFamilyPolicy
+		}
 		if len(d.Service.Spec.Ports) != 1 {
 			d.Service.Spec.Ports = []corev1.ServicePort{{}}
 		}
@@ -98,20 +165,27 @@ func (d *rsyncSvcDescription) Reconcile(l logr.Logger) (bool, error) {
 	return true, nil
 }
 
-func getServiceAddress(svc *corev1.Service) string {
-	address := svc.Spec.ClusterIP
-	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
-		if len(svc.Status.LoadBalancer.Ingress) > 0 {
-			if svc.Status.LoadBalancer.Ingress[0].Hostname != "" {
-				address = svc.Status.LoadBalancer.Ingress[0].Hostname
-			} else if svc.Status.LoadBalancer.Ingress[0].IP != "" {
-				address = svc.Status.LoadBalancer.Ingress[0].IP
-			}
-		} else {
-			address = ""
+// applyManagedKeys sets the desired entries in m and removes those that were
+// applied previously but are no longer desired. The applied keys are recorded
+// in the trackingKey annotation.
+func applyManagedKeys(m map[string]string, desired map[string]string,
+	annotations map[string]string, trackingKey string) {
+	for _, k := range strings.Split(annotations[trackingKey], ",") {
+		if _, ok := desired[k]; !ok {
+			delete(m, k)
 		}
 	}
-	return address
+	keys := []string{}
+	for k, v := range desired {
+		m[k] = v
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		delete(annotations, trackingKey)
+		return
+	}
+	sort.Strings(keys)
+	annotations[trackingKey] = strings.Join(keys, ",")
 }
 
 func getAndValidateSecret(ctx context.Context, client client.Client, logger logr.Logger,
@@ -140,15 +214,188 @@ func secretHasFields(secret *corev1.Secret, fields []string) error {
 	return nil
 }
 
+// getRsyncTimeout returns the description of the timeout reported by a
+// failed mover Pod of the Job, or "" if none of them timed out
+func getRsyncTimeout(ctx context.Context, c client.Client, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodFailed {
+			continue
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Terminated != nil && strings.HasPrefix(cs.State.Terminated.Message, rsyncTimeoutPrefix) {
+				return strings.TrimPrefix(cs.State.Terminated.Message, rsyncTimeoutPrefix), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// rsyncJumpHost is a parsed RsyncProxySpec.JumpHost
+type rsyncJumpHost struct {
+	User string
+	Host string
+	Port string
+}
+
+// parseJumpHost splits a jump host of the form [user@]host[:port] into its
+// components, applying defaults for those that are omitted
+func parseJumpHost(jumpHost string) (*rsyncJumpHost, error) {
+	jh := &rsyncJumpHost{User: "root", Port: "22"}
+	hostPort := jumpHost
+	if i := strings.LastIndex(jumpHost, "@"); i >= 0 {
+		jh.User = jumpHost[:i]
+		hostPort = jumpHost[i+1:]
+	}
+	if host, port, err := net.SplitHostPort(hostPort); err == nil {
+		jh.Host = host
+		jh.Port = port
+	} else {
+		// No port was provided
+		jh.Host = strings.TrimSuffix(strings.TrimPrefix(hostPort, "["), "]")
+	}
+	if jh.User == "" || jh.Host == "" || strings.ContainsAny(jumpHost, " \t") {
+		return nil, fmt.Errorf("invalid jump host: %q", jumpHost)
+	}
+	if port, err := strconv.Atoi(jh.Port); err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid jump host port: %q", jh.Port)
+	}
+	return jh, nil
+}
+
+// parseHTTPProxy validates the URL of an HTTP proxy, returning its host:port.
+// Credentials must be provided in a Secret rather than in the URL.
+func parseHTTPProxy(proxyURL string) (string, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" || u.Host == "" || strings.ContainsAny(u.Host, " \t") {
+		return "", fmt.Errorf("invalid HTTP proxy URL: %q", u.Redacted())
+	}
+	if u.User != nil {
+		return "", errors.New("HTTP proxy credentials must be provided via httpProxyCredentials")
+	}
+	return u.Host, nil
+}
+
+// validateRsyncProxy ensures the proxy configuration is usable, including
+// that the Secret with the jump host keys is present
+func validateRsyncProxy(ctx context.Context, c client.Client, l logr.Logger,
+	namespace string, proxy *volsyncv1alpha1.RsyncProxySpec) error {
+	if proxy == nil {
+		return nil
+	}
+	if proxy.JumpHost != nil && proxy.HTTPProxy != nil {
+		return errors.New("only one of jumpHost or httpProxy may be specified")
+	}
+	if proxy.HTTPProxyCredentials != nil && proxy.HTTPProxy == nil {
+		return errors.New("httpProxyCredentials may only be specified with an httpProxy")
+	}
+	if proxy.HTTPProxy != nil {
+		if _, err := parseHTTPProxy(*proxy.HTTPProxy); err != nil {
+			return err
+		}
+		if proxy.HTTPProxyCredentials == nil {
+			return nil
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      *proxy.HTTPProxyCredentials,
+				Namespace: namespace,
+			},
+		}
+		return utils.GetAndValidateSecret(ctx, c, l, secret, proxyUsernameField, proxyPasswordField)
+	}
+	if proxy.JumpHost == nil {
+		return nil
+	}
+	if _, err := parseJumpHost(*proxy.JumpHost); err != nil {
+		return err
+	}
+	if proxy.JumpHostKeys == nil {
+		return errors.New("jumpHostKeys must be provided when using a jumpHost")
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *proxy.JumpHostKeys,
+			Namespace: namespace,
+		},
+	}
+	return utils.GetAndValidateSecret(ctx, c, l, secret, jumpKeyField, jumpHostKeyField)
+}
+
+// rsyncProxyEnv returns the environment variables that configure the mover
+// to connect through the proxy. The proxy must already be validated.
+func rsyncProxyEnv(proxy *volsyncv1alpha1.RsyncProxySpec) []corev1.EnvVar {
+	if proxy == nil {
+		return nil
+	}
+	if proxy.JumpHost != nil {
+		jh, err := parseJumpHost(*proxy.JumpHost)
+		if err != nil {
+			return nil
+		}
+		return []corev1.EnvVar{
+			{Name: "PROXY_JUMP_HOST", Value: jh.Host},
+			{Name: "PROXY_JUMP_PORT", Value: jh.Port},
+			{Name: "PROXY_JUMP_USER", Value: jh.User},
+		}
+	}
+	if proxy.HTTPProxy != nil {
+		hostPort, err := parseHTTPProxy(*proxy.HTTPProxy)
+		if err != nil {
+			return nil
+		}
+		env := []corev1.EnvVar{{Name: "PROXY_HTTP", Value: hostPort}}
+		if proxy.HTTPProxyCredentials != nil {
+			// The credentials are only referenced so they stay out of the Job
+			user := utils.EnvFromSecret(*proxy.HTTPProxyCredentials, proxyUsernameField, false)
+			user.Name = "PROXY_HTTP_USER"
+			password := utils.EnvFromSecret(*proxy.HTTPProxyCredentials, proxyPasswordField, false)
+			password.Name = "PROXY_HTTP_PASSWORD"
+			env = append(env, user, password)
+		}
+		return env
+	}
+	return nil
+}
+
+// rsyncProxyVolume returns the volume and mount for the jump host keys, if
+// they are needed
+func rsyncProxyVolume(proxy *volsyncv1alpha1.RsyncProxySpec) ([]corev1.Volume, []corev1.VolumeMount) {
+	if proxy == nil || proxy.JumpHost == nil || proxy.JumpHostKeys == nil {
+		return nil, nil
+	}
+	secretMode := int32(0600)
+	volume := corev1.Volume{Name: jumpKeysVolumeName, VolumeSource: corev1.VolumeSource{
+		Secret: &corev1.SecretVolumeSource{
+			SecretName:  *proxy.JumpHostKeys,
+			DefaultMode: &secretMode,
+		}},
+	}
+	mount := corev1.VolumeMount{Name: jumpKeysVolumeName, MountPath: jumpKeysMountPath}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}
+}
+
 type rsyncSSHKeys struct {
 	Context      context.Context
 	Client       client.Client
 	Scheme       *runtime.Scheme
 	Owner        metav1.Object
 	NameTemplate string
-	MainSecret   *corev1.Secret
-	SrcSecret    *corev1.Secret
-	DestSecret   *corev1.Secret
+	// IsSource is true if the keys are generated by the source side of the
+	// replication, false if by the destination
+	IsSource   bool
+	KeyType    *volsyncv1alpha1.SSHKeyType
+	Rotation   *volsyncv1alpha1.SSHKeyRotationSpec
+	MainSecret *corev1.Secret
+	SrcSecret  *corev1.Secret
+	DestSecret *corev1.Secret
 }
 
 func (k *rsyncSSHKeys) Reconcile(l logr.Logger) (bool, error) {
@@ -177,6 +424,8 @@ func (k *rsyncSSHKeys) Reconcile(l logr.Logger) (bool, error) {
 	)
 }
 
+var mainSecretKeys = []string{"source", "source.pub", "destination", "destination.pub"}
+
 func (k *rsyncSSHKeys) ensureMainSecret(l logr.Logger) (bool, error) {
 	// The secrets hold the ssh key pairs to ensure mutual authentication of the
 	// connection. The main secret holds both keys and is used ensure the source
@@ -186,6 +435,8 @@ func (k *rsyncSSHKeys) ensureMainSecret(l logr.Logger) (bool, error) {
 	// do much to reconcile the main secret. All we can do is:
 	// - Create it if it doesn't exist
 	// - Ensure the expected fields are present within
+	// - Rotate the keys when requested, retaining the previous keys for the
+	//   grace period
 	logger := l.WithValues("mainSecret", utils.NameFor(k.MainSecret))
 
 	// See if it exists and has the proper fields
@@ -195,16 +446,15 @@ func (k *rsyncSSHKeys) ensureMainSecret(l logr.Logger) (bool, error) {
 		return false, err
 	}
 	if err == nil { // found it, make sure it has the right fields
-		if secretHasFields(k.MainSecret, []string{"source", "source.pub", "destination", "destination.pub"}) != nil {
+		if !isValidMainSecret(k.MainSecret) {
 			logger.V(1).Info("deleting invalid secret")
 			if err = k.Client.Delete(k.Context, k.MainSecret); err != nil {
 				logger.Error(err, "failed to delete secret")
 			}
 			return false, err
 		}
-		// Secret is valid, we're done
-		logger.V(1).Info("secret is valid")
-		return true, nil
+		// Secret is valid, see if the keys need to change
+		return k.rotateMainSecret(logger, time.Now())
 	}
 
 	// Need to create the secret
@@ -221,25 +471,184 @@ func (k *rsyncSSHKeys) ensureMainSecret(l logr.Logger) (bool, error) {
 	return false, nil
 }
 
-func generateKeyPair(ctx context.Context, l logr.Logger) (private []byte, public []byte, err error) {
-	keydir, err := ioutil.TempDir("", "sshkeys")
-	if err != nil {
-		l.Error(err, "unable to create temporary directory")
-		return
+// previousKey returns the name of the field in the main secret that holds the
+// previous version of key (e.g., source.pub -> source-previous.pub)
+func previousKey(key string) string {
+	if strings.HasSuffix(key, ".pub") {
+		return strings.TrimSuffix(key, ".pub") + previousKeySuffix + ".pub"
 	}
-	defer os.RemoveAll(keydir)
-	filename := filepath.Join(keydir, "key")
-	if err = exec.CommandContext(ctx, "ssh-keygen", "-q", "-t", "rsa", "-b", "4096",
-		"-f", filename, "-C", "", "-N", "").Run(); err != nil {
-		return
+	return key + previousKeySuffix
+}
+
+// isValidMainSecret checks that the main secret has the current keys and
+// either all or none of the previous keys.
+func isValidMainSecret(secret *corev1.Secret) bool {
+	previous := 0
+	for _, key := range mainSecretKeys {
+		if _, found := secret.Data[key]; !found {
+			return false
+		}
+		if _, found := secret.Data[previousKey(key)]; found {
+			previous++
+		}
+	}
+	return len(secret.Data) == len(mainSecretKeys)+previous &&
+		(previous == 0 || previous == len(mainSecretKeys))
+}
+
+// rotateMainSecret ends an expired grace period or replaces the keys if a
+// rotation is due. Only one change is made per call.
+func (k *rsyncSSHKeys) rotateMainSecret(l logr.Logger, now time.Time) (bool, error) {
+	annotations := k.MainSecret.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if graceEnd, found := annotations[sshKeysGraceEndAnnotation]; found {
+		end, err := time.Parse(time.RFC3339, graceEnd)
+		if err == nil && now.Before(end) {
+			// Still in the grace period, so don't rotate again
+			return true, nil
+		}
+		l.Info("removing previous ssh keys")
+		for _, key := range mainSecretKeys {
+			delete(k.MainSecret.Data, previousKey(key))
+		}
+		delete(annotations, sshKeysGraceEndAnnotation)
+		k.MainSecret.SetAnnotations(annotations)
+		return k.updateMainSecret(l)
+	}
+
+	if !k.rotationDue(annotations, now) {
+		return true, nil
+	}
+
+	l.Info("rotating ssh keys")
+	previous := k.MainSecret.Data
+	if err := k.generateMainSecret(l); err != nil {
+		l.Error(err, "unable to generate main secret")
+		return false, err
+	}
+	gracePeriod := defaultSSHKeyGracePeriod
+	if k.Rotation != nil && k.Rotation.GracePeriod != nil {
+		gracePeriod = k.Rotation.GracePeriod.Duration
+	}
+	if gracePeriod > 0 {
+		for _, key := range mainSecretKeys {
+			k.MainSecret.Data[previousKey(key)] = previous[key]
+		}
+		annotations = k.MainSecret.GetAnnotations()
+		annotations[sshKeysGraceEndAnnotation] = now.Add(gracePeriod).Format(time.RFC3339)
+		k.MainSecret.SetAnnotations(annotations)
+	}
+	return k.updateMainSecret(l)
+}
+
+// rotationDue returns true if the keys have exceeded their maximum age or
+// rotation has been requested via the CR's annotation.
+func (k *rsyncSSHKeys) rotationDue(annotations map[string]string, now time.Time) bool {
+	if k.Owner.GetAnnotations()[rotateSSHKeysAnnotation] != annotations[sshKeysRotatedForAnnotation] {
+		return true
+	}
+	if k.Rotation == nil || k.Rotation.MaxAge == nil {
+		return false
 	}
-	if private, err = ioutil.ReadFile(filename); err != nil {
+	created, err := time.Parse(time.RFC3339, annotations[sshKeysCreatedAnnotation])
+	// Keys from before rotation was supported have no creation time, so
+	// they are considered expired
+	return err != nil || now.After(created.Add(k.Rotation.MaxAge.Duration))
+}
+
+func (k *rsyncSSHKeys) updateMainSecret(l logr.Logger) (bool, error) {
+	if err := k.Client.Update(k.Context, k.MainSecret); err != nil {
+		l.Error(err, "unable to update secret")
+		return false, err
+	}
+	l.V(1).Info("updated secret")
+	return false, nil
+}
+
+// generateKeyPair creates a new SSH key pair. The private key is PEM encoded in
+// a form that can be used directly by OpenSSH, and the public key is in
+// authorized_keys format.
+func generateKeyPair(keyType *volsyncv1alpha1.SSHKeyType) (private []byte, public []byte, err error) {
+	var pubKey crypto.PublicKey
+	if keyType != nil && *keyType == volsyncv1alpha1.SSHKeyTypeRSA {
+		var key *rsa.PrivateKey
+		if key, err = rsa.GenerateKey(rand.Reader, 4096); err != nil {
+			return
+		}
+		private = pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		})
+		pubKey = &key.PublicKey
+	} else {
+		var key ed25519.PrivateKey
+		if pubKey, key, err = ed25519.GenerateKey(rand.Reader); err != nil {
+			return
+		}
+		if private, err = marshalED25519PrivateKey(key); err != nil {
+			return
+		}
+	}
+	sshPub, err := ssh.NewPublicKey(pubKey)
+	if err != nil {
 		return
 	}
-	public, err = ioutil.ReadFile(filename + ".pub")
+	public = ssh.MarshalAuthorizedKey(sshPub)
 	return
 }
 
+// marshalED25519PrivateKey encodes an ed25519 key in the (unencrypted)
+// "openssh-key-v1" format, which is the only format OpenSSH accepts for
+// ed25519 keys.
+func marshalED25519PrivateKey(key ed25519.PrivateKey) ([]byte, error) {
+	pub, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	var check [4]byte
+	if _, err = rand.Read(check[:]); err != nil {
+		return nil, err
+	}
+	checkInt := binary.BigEndian.Uint32(check[:])
+	privBlock := struct {
+		Check1  uint32
+		Check2  uint32
+		KeyType string
+		Pub     []byte
+		Priv    []byte
+		Comment string
+	}{
+		Check1:  checkInt,
+		Check2:  checkInt,
+		KeyType: ssh.KeyAlgoED25519,
+		Pub:     key.Public().(ed25519.PublicKey),
+		Priv:    key,
+	}
+	block := ssh.Marshal(privBlock)
+	// Pad to the cipher block size (8 for "none")
+	for i := 1; len(block)%8 != 0; i++ {
+		block = append(block, byte(i))
+	}
+	envelope := struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{
+		CipherName:   "none",
+		KdfName:      "none",
+		NumKeys:      1,
+		PubKey:       pub.Marshal(),
+		PrivKeyBlock: block,
+	}
+	data := append([]byte("openssh-key-v1\x00"), ssh.Marshal(envelope)...)
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: data}), nil
+}
+
 func (k *rsyncSSHKeys) generateMainSecret(l logr.Logger) error {
 	k.MainSecret.Data = make(map[string][]byte, 4)
 	if err := ctrl.SetControllerReference(k.Owner, k.MainSecret, k.Scheme); err != nil {
@@ -247,7 +656,7 @@ func (k *rsyncSSHKeys) generateMainSecret(l logr.Logger) error {
 		return err
 	}
 
-	priv, pub, err := generateKeyPair(k.Context, l)
+	priv, pub, err := generateKeyPair(k.KeyType)
 	if err != nil {
 		l.Error(err, "unable to generate source ssh keys")
 		return err
@@ -255,7 +664,7 @@ func (k *rsyncSSHKeys) generateMainSecret(l logr.Logger) error {
 	k.MainSecret.Data["source"] = priv
 	k.MainSecret.Data["source.pub"] = pub
 
-	priv, pub, err = generateKeyPair(k.Context, l)
+	priv, pub, err = generateKeyPair(k.KeyType)
 	if err != nil {
 		l.Error(err, "unable to generate destination ssh keys")
 		return err
@@ -263,11 +672,45 @@ func (k *rsyncSSHKeys) generateMainSecret(l logr.Logger) error {
 	k.MainSecret.Data["destination"] = priv
 	k.MainSecret.Data["destination.pub"] = pub
 
-	l.V(1).Info("created secret")
+	annotations := k.MainSecret.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[sshKeysCreatedAnnotation] = time.Now().Format(time.RFC3339)
+	annotations[sshKeysRotatedForAnnotation] = k.Owner.GetAnnotations()[rotateSSHKeysAnnotation]
+	k.MainSecret.SetAnnotations(annotations)
+
+	l.V(1).Info("generated keys")
 	return nil
 }
 
-func (k *rsyncSSHKeys) ensureSecret(l logr.Logger, secret *corev1.Secret, keys []string) (bool, error) {
+// sshSecretData generates the contents of the Secret used by one side of the
+// replication ("source" or "destination"). During a rotation grace period, the
+// side that generated the keys continues to use its previous identity (so it
+// can still connect to a peer that hasn't been updated), while the Secret for
+// the peer gets the new identity. Both sides accept either of the other's keys.
+func sshSecretData(main *corev1.Secret, side string, peer string, isLocal bool) map[string][]byte {
+	_, inGrace := main.Data[previousKey(side)]
+	identity := side
+	if inGrace && isLocal {
+		identity = previousKey(side)
+	}
+	peerPub := main.Data[peer+".pub"]
+	if inGrace {
+		peerPub = bytes.Join([][]byte{
+			bytes.TrimSpace(main.Data[peer+".pub"]),
+			bytes.TrimSpace(main.Data[previousKey(peer+".pub")]),
+		}, []byte("\n"))
+		peerPub = append(peerPub, '\n')
+	}
+	return map[string][]byte{
+		side:          main.Data[identity],
+		side + ".pub": main.Data[identity+".pub"],
+		peer + ".pub": peerPub,
+	}
+}
+
+func (k *rsyncSSHKeys) ensureSecret(l logr.Logger, secret *corev1.Secret, data map[string][]byte) (bool, error) {
 	logger := l.WithValues("secret", utils.NameFor(secret))
 
 	op, err := ctrlutil.CreateOrUpdate(k.Context, k.Client, secret, func() error {
@@ -275,12 +718,7 @@ func (k *rsyncSSHKeys) ensureSecret(l logr.Logger, secret *corev1.Secret, keys [
 			logger.Error(err, "unable to set controller reference")
 			return err
 		}
-		if secret.Data == nil {
-			secret.Data = make(map[string][]byte, 3)
-		}
-		for _, key := range keys {
-			secret.Data[key] = k.MainSecret.Data[key]
-		}
+		secret.Data = data
 		return nil
 	})
 	if err != nil {
@@ -293,10 +731,19 @@ func (k *rsyncSSHKeys) ensureSecret(l logr.Logger, secret *corev1.Secret, keys [
 
 func (k *rsyncSSHKeys) ensureSrcSecret(l logr.Logger) (bool, error) {
 	logger := l.WithValues("sourceSecret", utils.NameFor(k.SrcSecret))
-	return k.ensureSecret(logger, k.SrcSecret, []string{"source", "source.pub", "destination.pub"})
+	return k.ensureSecret(logger, k.SrcSecret, sshSecretData(k.MainSecret, "source", "destination", k.IsSource))
 }
 
 func (k *rsyncSSHKeys) ensureDestSecret(l logr.Logger) (bool, error) {
 	logger := l.WithValues("destSecret", utils.NameFor(k.DestSecret))
-	return k.ensureSecret(logger, k.DestSecret, []string{"destination", "destination.pub", "source.pub"})
+	return k.ensureSecret(logger, k.DestSecret, sshSecretData(k.MainSecret, "destination", "source", !k.IsSource))
+}
+
+// timeoutEnv formats an optional timeout for the mover, where "0" means there
+// is no limit
+func timeoutEnv(seconds *int64) string {
+	if seconds == nil {
+		return "0"
+	}
+	return strconv.FormatInt(*seconds, 10)
 }
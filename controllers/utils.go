@@ -22,7 +22,9 @@ import (
 
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	"github.com/go-logr/logr"
+	"github.com/operator-framework/operator-lib/status"
 	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
@@ -71,6 +73,22 @@ var (
 		},
 		metricLabels,
 	)
+	gcStaleObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "gc_stale_objects",
+			Namespace: metricsNamespace,
+			Help:      "The number of stale temporary objects found by the most recent orphan collection",
+		},
+		[]string{"kind", "reason"},
+	)
+	gcDeletedObjects = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "gc_deleted_objects_total",
+			Namespace: metricsNamespace,
+			Help:      "The number of stale temporary objects deleted by the orphan collector",
+		},
+		[]string{"kind", "reason"},
+	)
 )
 
 func newVolSyncMetrics(labels prometheus.Labels) volsyncMetrics {
@@ -83,7 +101,7 @@ func newVolSyncMetrics(labels prometheus.Labels) volsyncMetrics {
 
 func init() {
 	// Register custom metrics with the global prometheus registry
-	metrics.Registry.MustRegister(missedIntervals, outOfSync, syncDurations)
+	metrics.Registry.MustRegister(missedIntervals, outOfSync, syncDurations, gcStaleObjects, gcDeletedObjects)
 }
 
 //nolint:funlen
@@ -100,6 +118,9 @@ func (r *ReplicationSourceReconciler) countReplicationMethods(instance *volsyncv
 	if instance.Spec.Restic != nil {
 		numOfReplication++
 	}
+	if instance.Spec.RsyncTLS != nil {
+		numOfReplication++
+	}
 	if instance.Spec.External != nil {
 		numOfReplication++
 	}
@@ -121,9 +142,31 @@ func (r *ReplicationDestinationReconciler) countReplicationMethods(instance *vol
 	if instance.Spec.Restic != nil {
 		numOfReplication++
 	}
+	if instance.Spec.RsyncTLS != nil {
+		numOfReplication++
+	}
 	if instance.Spec.External != nil {
 		numOfReplication++
 	}
 	logger.Info("Counting over ", "Number of Replication Methods: ", numOfReplication)
 	return numOfReplication
 }
+
+// markSyncInProgress sets the Synchronizing condition for a synchronization
+// that is in progress. A timeout that was reported during the synchronization
+// is kept until it completes.
+func markSyncInProgress(conditions *status.Conditions) {
+	cond := conditions.GetCondition(volsyncv1alpha1.ConditionSynchronizing)
+	if cond != nil && cond.Status == corev1.ConditionTrue &&
+		cond.Reason == volsyncv1alpha1.SynchronizingReasonTimedOut {
+		return
+	}
+	conditions.SetCondition(
+		status.Condition{
+			Type:    volsyncv1alpha1.ConditionSynchronizing,
+			Status:  corev1.ConditionTrue,
+			Reason:  volsyncv1alpha1.SynchronizingReasonSync,
+			Message: "Synchronization in-progress",
+		},
+	)
+}
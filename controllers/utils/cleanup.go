@@ -22,6 +22,7 @@ import (
 
 	"github.com/go-logr/logr"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -39,6 +40,17 @@ func MarkForCleanup(owner metav1.Object, obj metav1.Object) {
 	obj.SetLabels(labels)
 }
 
+// MarkedForCleanup selects the objects that have been marked for cleanup by any
+// owner
+func MarkedForCleanup() client.ListOption {
+	return client.HasLabels{cleanupLabelKey}
+}
+
+// CleanupOwner returns the UID of the owner that marked "obj" for cleanup
+func CleanupOwner(obj metav1.Object) types.UID {
+	return types.UID(obj.GetLabels()[cleanupLabelKey])
+}
+
 // CleanupObjects deletes all objects that have been marked. The objects to be
 // cleaned up must have been previously marked via MarkForCleanup() and
 // associated with "owner". The "types" array should contain one object of each
@@ -23,7 +23,10 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -35,30 +38,58 @@ const DefaultSCCName = "volsync-mover"
 // SCCName is the name of the SCC to use for the mover Jobs
 var SCCName string
 
+// SCCAvailable is set if the cluster supports SecurityContextConstraints. The
+// mover ServiceAccounts are only granted use of the SCC if it is.
+var SCCAvailable = true
+
 type SAHandler struct {
 	Context     context.Context
 	Client      client.Client
 	SA          *corev1.ServiceAccount
 	Owner       metav1.Object
+	existingSA  *string
+	rbacName    string
 	role        *rbacv1.Role
 	roleBinding *rbacv1.RoleBinding
 }
 
-func NewSAHandler(ctx context.Context, c client.Client, owner metav1.Object, sa *corev1.ServiceAccount) SAHandler {
+// NewSAHandler returns a handler for the ServiceAccount that a mover runs as.
+// If existingSA names a ServiceAccount, it is used instead of creating "sa",
+// and the name of "sa" is updated to match.
+func NewSAHandler(ctx context.Context, c client.Client, owner metav1.Object, sa *corev1.ServiceAccount,
+	existingSA *string) SAHandler {
 	return SAHandler{
-		Context: ctx,
-		Client:  c,
-		SA:      sa,
-		Owner:   owner,
+		Context:    ctx,
+		Client:     c,
+		SA:         sa,
+		Owner:      owner,
+		existingSA: existingSA,
+		rbacName:   sa.Name,
 	}
 }
 
 func (d *SAHandler) Reconcile(l logr.Logger) (bool, error) {
-	return ReconcileBatch(l,
-		d.ensureSA,
-		d.ensureRole,
-		d.ensureRoleBinding,
-	)
+	steps := []ReconcileFunc{d.ensureSA}
+	if d.existingSA != nil {
+		steps = []ReconcileFunc{d.getExistingSA}
+	}
+	if SCCAvailable {
+		steps = append(steps, d.ensureRole, d.ensureRoleBinding)
+	}
+	return ReconcileBatch(l, steps...)
+}
+
+// getExistingSA uses a ServiceAccount that was provided by the user. Other than
+// the use of the SCC, any permissions that the mover needs must be granted to
+// it by the user.
+func (d *SAHandler) getExistingSA(l logr.Logger) (bool, error) {
+	d.SA.Name = *d.existingSA
+	logger := l.WithValues("ServiceAccount", NameFor(d.SA))
+	if err := d.Client.Get(d.Context, NameFor(d.SA), d.SA); err != nil {
+		logger.Error(err, "unable to get ServiceAccount")
+		return false, err
+	}
+	return true, nil
 }
 
 func (d *SAHandler) ensureSA(l logr.Logger) (bool, error) {
@@ -82,7 +113,7 @@ func (d *SAHandler) ensureSA(l logr.Logger) (bool, error) {
 func (d *SAHandler) ensureRole(l logr.Logger) (bool, error) {
 	d.role = &rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      d.SA.Name,
+			Name:      d.rbacName,
 			Namespace: d.SA.Namespace,
 		},
 	}
@@ -116,7 +147,7 @@ func (d *SAHandler) ensureRole(l logr.Logger) (bool, error) {
 func (d *SAHandler) ensureRoleBinding(l logr.Logger) (bool, error) {
 	d.roleBinding = &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      d.SA.Name,
+			Name:      d.rbacName,
 			Namespace: d.SA.Namespace,
 		},
 	}
@@ -144,3 +175,25 @@ func (d *SAHandler) ensureRoleBinding(l logr.Logger) (bool, error) {
 	logger.V(1).Info("RoleBinding reconciled", "operation", op)
 	return true, nil
 }
+
+// DetectSCC uses discovery to determine whether the cluster supports
+// SecurityContextConstraints
+func DetectSCC(cfg *rest.Config) (bool, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return false, err
+	}
+	resources, err := dc.ServerResourcesForGroupVersion("security.openshift.io/v1")
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "securitycontextconstraints" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
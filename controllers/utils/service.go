@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package utils
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ServiceAddresses returns the addresses at which the Service can be
+// reached, primary address first, and the corresponding port. An empty list is
+// returned if the addresses are not yet known.
+func ServiceAddresses(ctx context.Context, c client.Client, svc *corev1.Service) ([]string, int32, error) {
+	var port int32
+	if len(svc.Spec.Ports) > 0 {
+		port = svc.Spec.Ports[0].Port
+	}
+
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		addresses := []string{}
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				addresses = append(addresses, ingress.Hostname)
+			} else if ingress.IP != "" {
+				addresses = append(addresses, ingress.IP)
+			}
+		}
+		return addresses, port, nil
+	case corev1.ServiceTypeNodePort:
+		if len(svc.Spec.Ports) == 0 || svc.Spec.Ports[0].NodePort == 0 {
+			return nil, 0, nil
+		}
+		addresses, err := nodeAddresses(ctx, c)
+		return addresses, svc.Spec.Ports[0].NodePort, err
+	default:
+		// ClusterIPs holds both families for a dual-stack Service
+		if len(svc.Spec.ClusterIPs) > 0 {
+			return svc.Spec.ClusterIPs, port, nil
+		}
+		if svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != corev1.ClusterIPNone {
+			return []string{svc.Spec.ClusterIP}, port, nil
+		}
+		return nil, port, nil
+	}
+}
+
+// nodeAddresses returns the addresses of a Ready Node for use with a
+// NodePort Service. External addresses are preferred over internal ones.
+func nodeAddresses(ctx context.Context, c client.Client) ([]string, error) {
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+	// Choose consistently so the published address doesn't change
+	sort.Slice(nodes.Items, func(i, j int) bool {
+		return nodes.Items[i].Name < nodes.Items[j].Name
+	})
+	for _, addrType := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+		for _, node := range nodes.Items {
+			if !isNodeReady(&node) {
+				continue
+			}
+			addresses := []string{}
+			for _, addr := range node.Status.Addresses {
+				if addr.Type == addrType {
+					addresses = append(addresses, addr.Address)
+				}
+			}
+			if len(addresses) > 0 {
+				return addresses, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
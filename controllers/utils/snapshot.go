@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package utils
+
+import (
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	// SnapshotV1 is the preferred version of the snapshot API
+	SnapshotV1 = snapv1.SchemeGroupVersion
+	// SnapshotV1beta1 is the snapshot API version served by older clusters
+	SnapshotV1beta1 = schema.GroupVersion{Group: snapv1.GroupName, Version: "v1beta1"}
+)
+
+// DetectSnapshotVersion uses discovery to determine which version of the
+// snapshot.storage.k8s.io API the cluster serves. v1 is preferred, and v1beta1
+// is used only if it's the sole version available. If the API isn't installed
+// at all, v1 is returned.
+func DetectSnapshotVersion(cfg *rest.Config) (schema.GroupVersion, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return schema.GroupVersion{}, err
+	}
+	groups, err := dc.ServerGroups()
+	if err != nil {
+		return schema.GroupVersion{}, err
+	}
+	for _, group := range groups.Groups {
+		if group.Name != snapv1.GroupName {
+			continue
+		}
+		for _, version := range group.Versions {
+			if version.Version == SnapshotV1.Version {
+				return SnapshotV1, nil
+			}
+		}
+		for _, version := range group.Versions {
+			if version.Version == SnapshotV1beta1.Version {
+				return SnapshotV1beta1, nil
+			}
+		}
+	}
+	return SnapshotV1, nil
+}
+
+// AddSnapshotToScheme registers the snapshot types with the scheme under the
+// given version. The v1 Go types are used for both v1 and v1beta1 since the
+// fields that VolSync uses are the same in both versions. Only a single
+// version may be registered with a scheme.
+func AddSnapshotToScheme(s *runtime.Scheme, gv schema.GroupVersion) error {
+	s.AddKnownTypes(gv,
+		&snapv1.VolumeSnapshot{},
+		&snapv1.VolumeSnapshotList{},
+		&snapv1.VolumeSnapshotClass{},
+		&snapv1.VolumeSnapshotClassList{},
+		&snapv1.VolumeSnapshotContent{},
+		&snapv1.VolumeSnapshotContentList{},
+	)
+	metav1.AddToGroupVersion(s, gv)
+	return nil
+}
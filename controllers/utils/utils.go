@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -75,3 +76,53 @@ func EnvFromSecret(secretName string, field string, optional bool) v1.EnvVar {
 		},
 	}
 }
+
+// JobDeadlineExceeded returns true if the Job has been terminated because it
+// ran longer than its ActiveDeadlineSeconds.
+func JobDeadlineExceeded(job *batchv1.Job) bool {
+	if job.Spec.ActiveDeadlineSeconds == nil {
+		return false
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == v1.ConditionTrue &&
+			c.Reason == "DeadlineExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+// BlockDevicePath is the location where block-mode volumes are attached in
+// mover containers
+const BlockDevicePath = "/dev/block"
+
+// IsBlockVolume returns true if the PVC is a raw block volume
+func IsBlockVolume(pvc *v1.PersistentVolumeClaim) bool {
+	return pvc.Spec.VolumeMode != nil && *pvc.Spec.VolumeMode == v1.PersistentVolumeBlock
+}
+
+// AttachDataVolume attaches the named volume, which is backed by pvc, to the
+// container. Filesystem volumes are mounted at mountPath, and block volumes
+// are attached as a device at BlockDevicePath. Any previous attachment of the
+// volume is replaced.
+func AttachDataVolume(c *v1.Container, name string, pvc *v1.PersistentVolumeClaim, mountPath string) {
+	mounts := []v1.VolumeMount{}
+	for _, m := range c.VolumeMounts {
+		if m.Name != name {
+			mounts = append(mounts, m)
+		}
+	}
+	var devices []v1.VolumeDevice
+	for _, d := range c.VolumeDevices {
+		if d.Name != name {
+			devices = append(devices, d)
+		}
+	}
+	if IsBlockVolume(pvc) {
+		devices = append(devices, v1.VolumeDevice{Name: name, DevicePath: BlockDevicePath})
+	} else {
+		mounts = append([]v1.VolumeMount{{Name: name, MountPath: mountPath}}, mounts...)
+	}
+	c.VolumeMounts = mounts
+	c.VolumeDevices = devices
+}
@@ -25,8 +25,9 @@ import (
 
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	"github.com/backube/volsync/controllers/utils"
+	"github.com/backube/volsync/controllers/volumehandler"
 	"github.com/go-logr/logr"
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -39,6 +40,8 @@ import (
 const (
 	// Annotation used to track the name of the snapshot being created
 	snapshotAnnotation = "volsync.backube/snapname"
+	// Annotation used to track the name of the clone being created
+	cloneAnnotation = "volsync.backube/clonename"
 	// Time format for snapshot names and labels
 	timeYYYYMMDDHHMMSS = "20060102150405"
 )
@@ -50,6 +53,7 @@ type destinationVolumeHandler struct {
 	Options  *volsyncv1alpha1.ReplicationDestinationVolumeOptions
 	PVC      *v1.PersistentVolumeClaim
 	Snapshot *snapv1.VolumeSnapshot
+	Clone    *v1.PersistentVolumeClaim
 }
 
 func (h *destinationVolumeHandler) useProvidedPVC(l logr.Logger) (bool, error) {
@@ -99,6 +103,9 @@ func (h *destinationVolumeHandler) EnsurePVC(l logr.Logger) (bool, error) {
 			h.PVC.Spec.AccessModes = h.Options.AccessModes
 			h.PVC.Spec.StorageClassName = h.Options.StorageClassName
 			volumeMode := corev1.PersistentVolumeFilesystem
+			if h.Options.VolumeMode != nil {
+				volumeMode = *h.Options.VolumeMode
+			}
 			h.PVC.Spec.VolumeMode = &volumeMode
 		}
 
@@ -162,12 +169,9 @@ func (h *destinationVolumeHandler) createSnapshot(l logr.Logger) (bool, error) {
 	}
 	logger.V(1).Info("Snapshot reconciled", "operation", op)
 
-	// We only continue reconciling if the snapshot has been bound
-	if h.Snapshot.Status == nil || h.Snapshot.Status.BoundVolumeSnapshotContentName == nil {
-		return false, nil
-	}
-
-	return true, nil
+	// We only continue reconciling if the snapshot is ready to use
+	return volumehandler.CheckSnapshot(h.Ctx, h.Client, logger, h.Snapshot,
+		volumehandler.SnapshotTimeout(h.Options.SnapshotTimeoutSeconds))
 }
 
 func (h *destinationVolumeHandler) cleanupOldSnapshot(l logr.Logger) (bool, error) {
@@ -228,6 +232,128 @@ func (h *destinationVolumeHandler) removeSnapshotAnnotation(l logr.Logger) (bool
 	return true, nil
 }
 
+func (h *destinationVolumeHandler) createClone(l logr.Logger) (bool, error) {
+	// Track the name of the (in-progress) clone as a PVC annotation
+	cloneName := types.NamespacedName{Namespace: h.Instance.Namespace}
+	if h.PVC.Annotations == nil {
+		h.PVC.Annotations = make(map[string]string)
+	}
+	if name, ok := h.PVC.Annotations[cloneAnnotation]; ok {
+		cloneName.Name = name
+	} else {
+		ts := time.Now().Format(timeYYYYMMDDHHMMSS)
+		cloneName.Name = "volsync-dest-" + h.Instance.Name + "-" + ts
+		h.PVC.Annotations[cloneAnnotation] = cloneName.Name
+		if err := h.Client.Update(h.Ctx, h.PVC); err != nil {
+			l.Error(err, "unable to update PVC")
+			return false, err
+		}
+	}
+	logger := l.WithValues("clone", cloneName)
+
+	h.Clone = &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cloneName.Name,
+			Namespace: cloneName.Namespace,
+		},
+	}
+	op, err := ctrlutil.CreateOrUpdate(h.Ctx, h.Client, h.Clone, func() error {
+		if err := ctrl.SetControllerReference(h.Instance, h.Clone, h.Scheme); err != nil {
+			logger.Error(err, "unable to set controller reference")
+			return err
+		}
+		if h.Clone.CreationTimestamp.IsZero() {
+			h.Clone.Spec.AccessModes = h.PVC.Spec.AccessModes
+			h.Clone.Spec.Resources.Requests = corev1.ResourceList{
+				corev1.ResourceStorage: *h.PVC.Spec.Resources.Requests.Storage(),
+			}
+			h.Clone.Spec.StorageClassName = h.PVC.Spec.StorageClassName
+			h.Clone.Spec.VolumeMode = h.PVC.Spec.VolumeMode
+			h.Clone.Spec.DataSource = &corev1.TypedLocalObjectReference{
+				APIGroup: nil,
+				Kind:     "PersistentVolumeClaim",
+				Name:     h.PVC.Name,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "reconcile failed")
+		return false, err
+	}
+	logger.V(1).Info("Clone reconciled", "operation", op)
+
+	// We only continue reconciling once the clone has been provisioned
+	return volumehandler.CheckClone(h.Ctx, h.Client, logger, h.PVC, h.Clone)
+}
+
+func (h *destinationVolumeHandler) cleanupOldClone(l logr.Logger) (bool, error) {
+	// Make sure we only delete an old clone (it's a PVC that we created, but
+	// not the one we replicate into or the current clone)
+
+	// There's no latestImage
+	if h.Instance.Status.LatestImage == nil {
+		return true, nil
+	}
+	// LatestImage is not a PVC
+	if h.Instance.Status.LatestImage.Kind != "PersistentVolumeClaim" ||
+		(h.Instance.Status.LatestImage.APIGroup != nil && *h.Instance.Status.LatestImage.APIGroup != "") {
+		return true, nil
+	}
+	if h.Instance.Status.LatestImage.Name == h.PVC.Name ||
+		(h.Clone != nil && h.Instance.Status.LatestImage.Name == h.Clone.Name) {
+		return true, nil
+	}
+
+	oldClone := &v1.PersistentVolumeClaim{}
+	cloneName := types.NamespacedName{Name: h.Instance.Status.LatestImage.Name, Namespace: h.Instance.Namespace}
+	if err := h.Client.Get(h.Ctx, cloneName, oldClone); err != nil {
+		if kerrors.IsNotFound(err) {
+			return true, nil
+		}
+		l.Error(err, "unable to get old clone")
+		return false, err
+	}
+	// Never delete a PVC that we didn't create (e.g., a previous
+	// destinationPVC)
+	if !metav1.IsControlledBy(oldClone, h.Instance) {
+		return true, nil
+	}
+	err := h.Client.Delete(h.Ctx, oldClone)
+	if err != nil && !kerrors.IsNotFound(err) {
+		l.Error(err, "unable to delete old clone")
+		return false, err
+	}
+	// Don't need to force the status update
+	h.Instance.Status.LatestImage = nil
+	l.Info("Old clone deleted.", "clonename", cloneName)
+	return true, nil
+}
+
+func (h *destinationVolumeHandler) recordNewClone(l logr.Logger) (bool, error) {
+	coreAPI := ""
+	h.Instance.Status.LatestImage = &v1.TypedLocalObjectReference{
+		APIGroup: &coreAPI,
+		Kind:     "PersistentVolumeClaim",
+		Name:     h.Clone.Name,
+	}
+	err := h.Status().Update(h.Ctx, h.Instance)
+	if err != nil {
+		l.Error(err, "unable to save clone name")
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *destinationVolumeHandler) removeCloneAnnotation(l logr.Logger) (bool, error) {
+	delete(h.PVC.Annotations, cloneAnnotation)
+	if err := h.Client.Update(h.Ctx, h.PVC); err != nil {
+		l.Error(err, "unable to remove clone annotation from PVC")
+		return false, err
+	}
+	return true, nil
+}
+
 func (h *destinationVolumeHandler) recordPVC(l logr.Logger) (bool, error) {
 	coreAPI := ""
 	h.Instance.Status.LatestImage = &v1.TypedLocalObjectReference{
@@ -249,6 +375,7 @@ func (h *destinationVolumeHandler) PreserveImage(l logr.Logger) (bool, error) {
 	if h.Options.CopyMethod == volsyncv1alpha1.CopyMethodNone {
 		return utils.ReconcileBatch(l,
 			h.cleanupOldSnapshot,
+			h.cleanupOldClone,
 			h.recordPVC,
 		)
 	}
@@ -256,11 +383,21 @@ func (h *destinationVolumeHandler) PreserveImage(l logr.Logger) (bool, error) {
 		return utils.ReconcileBatch(l,
 			h.createSnapshot,
 			h.cleanupOldSnapshot,
+			h.cleanupOldClone,
 			h.recordNewSnapshot,
 			h.removeSnapshotAnnotation,
 		)
 	}
-	return false, fmt.Errorf("unsupported copyMethod: %v -- must be None or Snapshot", h.Options.CopyMethod)
+	if h.Options.CopyMethod == volsyncv1alpha1.CopyMethodClone {
+		return utils.ReconcileBatch(l,
+			h.createClone,
+			h.cleanupOldSnapshot,
+			h.cleanupOldClone,
+			h.recordNewClone,
+			h.removeCloneAnnotation,
+		)
+	}
+	return false, fmt.Errorf("unsupported copyMethod: %v -- must be None, Clone, or Snapshot", h.Options.CopyMethod)
 }
 
 type sourceVolumeHandler struct {
@@ -291,6 +428,17 @@ func (h *sourceVolumeHandler) CleanupPVC(l logr.Logger) (bool, error) {
 	return true, nil
 }
 
+// validateCopyMethod rejects the copy methods that are only supported by the
+// movers in the catalog
+func (h *sourceVolumeHandler) validateCopyMethod(l logr.Logger) (bool, error) {
+	if h.Options.CopyMethod == volsyncv1alpha1.CopyMethodAuto {
+		err := errors.New("copyMethod Auto is only supported by the restic and rsyncTLS movers")
+		l.V(1).Info("spec validation failed", "reason", err.Error())
+		return false, err
+	}
+	return true, nil
+}
+
 // Ensures there is a source PVC to sync from, using whatever method is
 // specified by CopyMethod.
 func (h *sourceVolumeHandler) EnsurePVC(l logr.Logger) (bool, error) {
@@ -353,6 +501,7 @@ func (h *sourceVolumeHandler) pvcFromSnap(l logr.Logger) (bool, error) {
 			} else {
 				h.PVC.Spec.AccessModes = h.srcPVC.Spec.AccessModes
 			}
+			h.PVC.Spec.VolumeMode = h.srcPVC.Spec.VolumeMode
 			h.PVC.Spec.DataSource = &v1.TypedLocalObjectReference{
 				APIGroup: &snapv1.SchemeGroupVersion.Group,
 				Kind:     "VolumeSnapshot",
@@ -394,9 +543,9 @@ func (h *sourceVolumeHandler) snapshotSrc(l logr.Logger) (bool, error) {
 		return false, err
 	}
 
-	if h.srcSnap.Status == nil || h.srcSnap.Status.BoundVolumeSnapshotContentName == nil {
-		logger.V(1).Info("waiting for snapshot to be bound")
-		return false, nil
+	if ready, err := volumehandler.CheckSnapshot(h.Ctx, h.Client, logger, h.srcSnap,
+		volumehandler.SnapshotTimeout(h.Options.SnapshotTimeoutSeconds)); !ready || err != nil {
+		return false, err
 	}
 
 	logger.V(1).Info("temporary snapshot reconciled", "operation", op)
@@ -441,6 +590,7 @@ func (h *sourceVolumeHandler) ensureClone(l logr.Logger) (bool, error) {
 			} else {
 				h.PVC.Spec.AccessModes = h.srcPVC.Spec.AccessModes
 			}
+			h.PVC.Spec.VolumeMode = h.srcPVC.Spec.VolumeMode
 			h.PVC.Spec.DataSource = &v1.TypedLocalObjectReference{
 				APIGroup: nil,
 				Kind:     "PersistentVolumeClaim",
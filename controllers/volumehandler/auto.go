@@ -0,0 +1,223 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package volumehandler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+)
+
+const (
+	// How long the automatically selected copy method may take before falling
+	// back to the next one, if snapshotTimeoutSeconds isn't specified
+	defaultAutoTimeout = 10 * time.Minute
+	// Annotation that records when the provisioning of a clone with
+	// WaitForFirstConsumer binding was requested
+	provisioningStartAnnotation = "volsync.backube/provisioning-start"
+)
+
+// autoCapabilities describes the copy methods that the storage of a volume
+// supports
+type autoCapabilities struct {
+	// methods in order of preference. None is always the last one.
+	methods []volsyncv1alpha1.CopyMethodType
+	// immediateBinding is true if new volumes are provisioned without waiting
+	// for a consumer. Otherwise, a clone is only provisioned once a node has
+	// been selected for it.
+	immediateBinding bool
+}
+
+// ensureAuto selects a copy method based on the capabilities of the storage of
+// src and uses it to create the PVC. The selection is recorded so that it is
+// reused by later syncs. If the selected method fails, the next supported
+// method is selected for the next attempt.
+func (vh *VolumeHandler) ensureAuto(ctx context.Context, log logr.Logger,
+	src *v1.PersistentVolumeClaim, name string, isTemporary bool) (*v1.PersistentVolumeClaim, error) {
+	caps, err := vh.autoCopyMethods(ctx, log, src)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := caps.methods[0]
+	if vh.selectedCopyMethod != nil && *vh.selectedCopyMethod != "" {
+		for _, m := range caps.methods {
+			if m == *vh.selectedCopyMethod {
+				selected = m
+			}
+		}
+	}
+	vh.recordCopyMethod(selected)
+	logger := log.WithValues("copyMethod", selected)
+
+	timeout := vh.snapshotTimeout
+	if timeout == 0 {
+		timeout = defaultAutoTimeout
+	}
+	var pvc *v1.PersistentVolumeClaim
+	switch selected { //nolint:exhaustive
+	case volsyncv1alpha1.CopyMethodSnapshot:
+		var snap *snapv1.VolumeSnapshot
+		snap, err = vh.ensureSnapshot(ctx, logger, src, name, isTemporary, timeout)
+		if snap != nil && err == nil {
+			pvc, err = vh.pvcFromSnapshot(ctx, logger, snap, src, name, isTemporary)
+		}
+	case volsyncv1alpha1.CopyMethodClone:
+		pvc, err = vh.ensureClone(ctx, logger, src, name, isTemporary)
+		if pvc != nil && err == nil && pvc.Status.Phase != v1.ClaimBound {
+			var start time.Time
+			start, err = vh.provisioningStart(ctx, logger, pvc, caps.immediateBinding)
+			if err == nil && !start.IsZero() && time.Since(start) > timeout {
+				err = vh.abandonClone(ctx, logger, pvc)
+			}
+		}
+	default:
+		return src, nil
+	}
+
+	var snapErr *SnapshotError
+	if errors.As(err, &snapErr) || errors.Is(err, errCloneTimeout) {
+		next := caps.methods[len(caps.methods)-1]
+		for i, m := range caps.methods {
+			if m == selected && i+1 < len(caps.methods) {
+				next = caps.methods[i+1]
+			}
+		}
+		logger.Info("copy method failed, falling back", "reason", err.Error(), "next", next)
+		vh.recordCopyMethod(next)
+		return nil, nil
+	}
+	return pvc, err
+}
+
+// errCloneTimeout indicates that a clone was not provisioned in time
+var errCloneTimeout = errors.New("timed out waiting for clone to be provisioned")
+
+// provisioningStart returns when the provisioning of the clone was requested,
+// or the zero time if it hasn't been yet. With WaitForFirstConsumer binding,
+// that is once a node has been selected for it (i.e., the mover's Pod has been
+// scheduled), which is recorded on the clone when it is first seen.
+func (vh *VolumeHandler) provisioningStart(ctx context.Context, log logr.Logger,
+	pvc *v1.PersistentVolumeClaim, immediateBinding bool) (time.Time, error) {
+	if immediateBinding {
+		return pvc.CreationTimestamp.Time, nil
+	}
+	if pvc.Annotations[selectedNodeAnnotation] == "" {
+		return time.Time{}, nil
+	}
+	if start, err := time.Parse(time.RFC3339, pvc.Annotations[provisioningStartAnnotation]); err == nil {
+		return start, nil
+	}
+	start := time.Now()
+	pvc.Annotations[provisioningStartAnnotation] = start.Format(time.RFC3339)
+	if err := vh.client.Update(ctx, pvc); err != nil {
+		log.Error(err, "unable to record the start of provisioning")
+		return time.Time{}, err
+	}
+	return start, nil
+}
+
+// abandonClone deletes a clone that was not provisioned in time
+func (vh *VolumeHandler) abandonClone(ctx context.Context, log logr.Logger, pvc *v1.PersistentVolumeClaim) error {
+	if err := vh.client.Delete(ctx, pvc); err != nil && !kerrors.IsNotFound(err) {
+		log.Error(err, "unable to delete clone")
+		return err
+	}
+	return errCloneTimeout
+}
+
+func (vh *VolumeHandler) recordCopyMethod(method volsyncv1alpha1.CopyMethodType) {
+	if vh.selectedCopyMethod != nil {
+		*vh.selectedCopyMethod = method
+	}
+}
+
+// autoCopyMethods determines which copy methods the storage of src supports.
+// Snapshot is supported if there is a VolumeSnapshotClass for the
+// provisioner, and Clone is supported if the provisioner is a CSI driver.
+func (vh *VolumeHandler) autoCopyMethods(ctx context.Context, log logr.Logger,
+	src *v1.PersistentVolumeClaim) (*autoCapabilities, error) {
+	caps := &autoCapabilities{}
+	sc, err := storageClassFor(ctx, vh.client, src)
+	if err != nil {
+		log.Error(err, "unable to determine StorageClass")
+		return nil, err
+	}
+	if sc != nil {
+		caps.immediateBinding = sc.VolumeBindingMode == nil ||
+			*sc.VolumeBindingMode == storagev1.VolumeBindingImmediate
+
+		snapshots, err := vh.hasSnapshotClass(ctx, sc.Provisioner)
+		if err != nil {
+			log.Error(err, "unable to check VolumeSnapshotClasses")
+			return nil, err
+		}
+		if snapshots {
+			caps.methods = append(caps.methods, volsyncv1alpha1.CopyMethodSnapshot)
+		}
+
+		driver := &storagev1.CSIDriver{}
+		err = vh.client.Get(ctx, types.NamespacedName{Name: sc.Provisioner}, driver)
+		if err == nil {
+			caps.methods = append(caps.methods, volsyncv1alpha1.CopyMethodClone)
+		} else if !kerrors.IsNotFound(err) {
+			log.Error(err, "unable to check CSIDriver")
+			return nil, err
+		}
+	}
+	caps.methods = append(caps.methods, volsyncv1alpha1.CopyMethodNone)
+	log.V(1).Info("supported copy methods", "methods", caps.methods)
+	return caps, nil
+}
+
+// hasSnapshotClass returns true if the configured VolumeSnapshotClass (or, if
+// none is configured, any VolumeSnapshotClass) is for the provisioner
+func (vh *VolumeHandler) hasSnapshotClass(ctx context.Context, provisioner string) (bool, error) {
+	if vh.volumeSnapshotClassName != nil {
+		vsc := &snapv1.VolumeSnapshotClass{}
+		err := vh.client.Get(ctx, types.NamespacedName{Name: *vh.volumeSnapshotClassName}, vsc)
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return err == nil && vsc.Driver == provisioner, err
+	}
+	vscList := &snapv1.VolumeSnapshotClassList{}
+	if err := vh.client.List(ctx, vscList); err != nil {
+		if meta.IsNoMatchError(err) {
+			// The snapshot CRDs aren't installed
+			return false, nil
+		}
+		return false, err
+	}
+	for _, vsc := range vscList.Items {
+		if vsc.Driver == provisioner {
+			return true, nil
+		}
+	}
+	return false, nil
+}
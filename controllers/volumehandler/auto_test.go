@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package volumehandler
+
+import (
+	"context"
+	"time"
+
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+)
+
+var _ = Describe("A VolumeHandler with a copyMethod of Auto", func() {
+	var ctx = context.TODO()
+	var ns *v1.Namespace
+	var rs *volsyncv1alpha1.ReplicationSource
+	var src *v1.PersistentVolumeClaim
+	var sc *storagev1.StorageClass
+	var vsc *snapv1.VolumeSnapshotClass
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+
+	BeforeEach(func() {
+		ns = &v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "vh-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		sc = &storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "auto-",
+			},
+			Provisioner: "auto.csi.example.com",
+		}
+		Expect(k8sClient.Create(ctx, sc)).To(Succeed())
+		vsc = &snapv1.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "auto-",
+			},
+			Driver:         sc.Provisioner,
+			DeletionPolicy: snapv1.VolumeSnapshotContentDelete,
+		}
+		Expect(k8sClient.Create(ctx, vsc)).To(Succeed())
+		src = &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "src",
+				Namespace: ns.Name,
+			},
+			Spec: v1.PersistentVolumeClaimSpec{
+				AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						"storage": resource.MustParse("1Gi"),
+					},
+				},
+				StorageClassName: &sc.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, src)).To(Succeed())
+		rs = &volsyncv1alpha1.ReplicationSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mysrc",
+				Namespace: ns.Name,
+			},
+			Spec: volsyncv1alpha1.ReplicationSourceSpec{
+				SourcePVC: src.Name,
+				Rsync: &volsyncv1alpha1.ReplicationSourceRsyncSpec{
+					ReplicationSourceVolumeOptions: volsyncv1alpha1.ReplicationSourceVolumeOptions{
+						CopyMethod: volsyncv1alpha1.CopyMethodAuto,
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, rs)).To(Succeed())
+		rs.Status = &volsyncv1alpha1.ReplicationSourceStatus{}
+	})
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, vsc)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, sc)).To(Succeed())
+	})
+
+	It("prefers a snapshot and falls back when it fails", func() {
+		vh, err := NewVolumeHandler(
+			WithClient(k8sClient),
+			WithOwner(rs),
+			FromSource(&rs.Spec.Rsync.ReplicationSourceVolumeOptions),
+			SelectedCopyMethod(&rs.Status.SelectedCopyMethod),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		pvc, err := vh.EnsurePVCFromSrc(ctx, logger, src, "copy", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pvc).To(BeNil())
+		Expect(rs.Status.SelectedCopyMethod).To(Equal(volsyncv1alpha1.CopyMethodSnapshot))
+
+		// Make the snapshot fail
+		snap := &snapv1.VolumeSnapshot{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "copy", Namespace: ns.Name}, snap)).To(Succeed())
+		message := "not supported"
+		snap.Status = &snapv1.VolumeSnapshotStatus{
+			Error: &snapv1.VolumeSnapshotError{Message: &message},
+		}
+		Expect(k8sClient.Status().Update(ctx, snap)).To(Succeed())
+
+		pvc, err = vh.EnsurePVCFromSrc(ctx, logger, src, "copy", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pvc).To(BeNil())
+		// There's no CSIDriver, so cloning isn't an option
+		Expect(rs.Status.SelectedCopyMethod).To(Equal(volsyncv1alpha1.CopyMethodNone))
+
+		pvc, err = vh.EnsurePVCFromSrc(ctx, logger, src, "copy", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pvc.Name).To(Equal(src.Name))
+	})
+
+	It("falls back from a clone with WaitForFirstConsumer binding that isn't provisioned", func() {
+		wffc := storagev1.VolumeBindingWaitForFirstConsumer
+		wffcSC := &storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "wffc-",
+			},
+			Provisioner:       "wffc.csi.example.com",
+			VolumeBindingMode: &wffc,
+		}
+		Expect(k8sClient.Create(ctx, wffcSC)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, wffcSC)).To(Succeed()) }()
+		driver := &storagev1.CSIDriver{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: wffcSC.Provisioner,
+			},
+		}
+		Expect(k8sClient.Create(ctx, driver)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, driver)).To(Succeed()) }()
+		wffcSrc := src.DeepCopy()
+		wffcSrc.ObjectMeta = metav1.ObjectMeta{
+			Name:      "wffcsrc",
+			Namespace: ns.Name,
+		}
+		wffcSrc.Spec.StorageClassName = &wffcSC.Name
+		Expect(k8sClient.Create(ctx, wffcSrc)).To(Succeed())
+
+		timeoutSeconds := int32(1)
+		rs.Spec.Rsync.SnapshotTimeoutSeconds = &timeoutSeconds
+		vh, err := NewVolumeHandler(
+			WithClient(k8sClient),
+			WithOwner(rs),
+			FromSource(&rs.Spec.Rsync.ReplicationSourceVolumeOptions),
+			SelectedCopyMethod(&rs.Status.SelectedCopyMethod),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		pvc, err := vh.EnsurePVCFromSrc(ctx, logger, wffcSrc, "copy", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pvc).NotTo(BeNil())
+		Expect(rs.Status.SelectedCopyMethod).To(Equal(volsyncv1alpha1.CopyMethodClone))
+
+		// The clone isn't abandoned before a node has been selected for it
+		time.Sleep(2 * time.Second)
+		pvc, err = vh.EnsurePVCFromSrc(ctx, logger, wffcSrc, "copy", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pvc).NotTo(BeNil())
+		Expect(rs.Status.SelectedCopyMethod).To(Equal(volsyncv1alpha1.CopyMethodClone))
+
+		// The mover's Pod is scheduled, but the clone isn't provisioned
+		pvc.Annotations = map[string]string{selectedNodeAnnotation: "node1"}
+		Expect(k8sClient.Update(ctx, pvc)).To(Succeed())
+		Eventually(func() volsyncv1alpha1.CopyMethodType {
+			_, err := vh.EnsurePVCFromSrc(ctx, logger, wffcSrc, "copy", true)
+			Expect(err).NotTo(HaveOccurred())
+			return rs.Status.SelectedCopyMethod
+		}, maxWait, interval).Should(Equal(volsyncv1alpha1.CopyMethodNone))
+	})
+
+	It("uses the source directly if the storage has no copy capabilities", func() {
+		noSC := ""
+		src.Spec.StorageClassName = &noSC
+		vh, err := NewVolumeHandler(
+			WithClient(k8sClient),
+			WithOwner(rs),
+			FromSource(&rs.Spec.Rsync.ReplicationSourceVolumeOptions),
+			SelectedCopyMethod(&rs.Status.SelectedCopyMethod),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		pvc, err := vh.EnsurePVCFromSrc(ctx, logger, src, "copy", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pvc.Name).To(Equal(src.Name))
+		Expect(rs.Status.SelectedCopyMethod).To(Equal(volsyncv1alpha1.CopyMethodNone))
+	})
+})
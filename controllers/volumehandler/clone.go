@@ -0,0 +1,189 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package volumehandler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Annotation used to track the name of the clone being created
+	cloneAnnotation = "volsync.backube/clonename"
+	// Label that marks a PVC as a preserved image of its owner. The value is
+	// the UID of the owner.
+	imageLabelKey = "volsync.backube/image"
+	// Annotation that marks the default StorageClass
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	// Annotation set by the scheduler on PVCs with WaitForFirstConsumer
+	// binding to request that they be provisioned on the node
+	selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+)
+
+// CheckClone returns true if the cloned PVC has been provisioned. The contents
+// of a clone are only captured when it is provisioned, which would be delayed
+// until it is used for a StorageClass with WaitForFirstConsumer binding. Such
+// clones are provisioned right away on the node of their source.
+func CheckClone(ctx context.Context, c client.Client, log logr.Logger,
+	src *v1.PersistentVolumeClaim, pvc *v1.PersistentVolumeClaim) (bool, error) {
+	if !pvc.DeletionTimestamp.IsZero() {
+		log.V(1).Info("clone is being deleted-- need to wait")
+		return false, nil
+	}
+	if pvc.Status.Phase == v1.ClaimBound {
+		return true, nil
+	}
+	sc, err := storageClassFor(ctx, c, pvc)
+	if err != nil {
+		log.Error(err, "unable to determine StorageClass")
+		return false, err
+	}
+	if sc != nil && sc.VolumeBindingMode != nil &&
+		*sc.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer &&
+		pvc.Annotations[selectedNodeAnnotation] == "" {
+		node := src.Annotations[selectedNodeAnnotation]
+		if node == "" {
+			err := fmt.Errorf("unable to provision clone of PVC %s: StorageClass %s uses WaitForFirstConsumer "+
+				"binding, and the PVC has no selected node", src.Name, sc.Name)
+			log.Error(err, "unable to provision clone")
+			return false, err
+		}
+		if pvc.Annotations == nil {
+			pvc.Annotations = map[string]string{}
+		}
+		pvc.Annotations[selectedNodeAnnotation] = node
+		if err := c.Update(ctx, pvc); err != nil {
+			log.Error(err, "unable to select node for clone")
+			return false, err
+		}
+		log.Info("provisioning clone on the node of its source", "node", node)
+	}
+	log.V(1).Info("waiting for clone to be provisioned")
+	return false, nil
+}
+
+func (vh *VolumeHandler) ensureImageClone(ctx context.Context, log logr.Logger,
+	src *v1.PersistentVolumeClaim) (*v1.PersistentVolumeClaim, error) {
+	// create & record name (if necessary)
+	if src.Annotations == nil {
+		src.Annotations = make(map[string]string)
+	}
+	if _, ok := src.Annotations[cloneAnnotation]; !ok {
+		ts := time.Now().Format(timeYYYYMMDDHHMMSS)
+		src.Annotations[cloneAnnotation] = src.Name + "-" + ts
+		if err := vh.client.Update(ctx, src); err != nil {
+			log.Error(err, "unable to annotate PVC")
+			return nil, err
+		}
+	}
+	cloneName := src.Annotations[cloneAnnotation]
+	logger := log.WithValues("clone", cloneName)
+
+	clone, err := vh.ensureClone(ctx, log, src, cloneName, false)
+	if clone == nil || err != nil {
+		return nil, err
+	}
+	if clone.Labels[imageLabelKey] != string(vh.owner.GetUID()) {
+		if clone.Labels == nil {
+			clone.Labels = make(map[string]string)
+		}
+		clone.Labels[imageLabelKey] = string(vh.owner.GetUID())
+		if err := vh.client.Update(ctx, clone); err != nil {
+			logger.Error(err, "unable to label clone")
+			return nil, err
+		}
+	}
+
+	// We only continue reconciling once the clone has been provisioned since
+	// the source will be overwritten by the next sync
+	if ready, err := CheckClone(ctx, vh.client, logger, src, clone); !ready || err != nil {
+		return nil, err
+	}
+
+	if err := vh.cleanupOldClones(ctx, logger, clone); err != nil {
+		return nil, err
+	}
+
+	// The next sync gets a new clone
+	delete(src.Annotations, cloneAnnotation)
+	if err := vh.client.Update(ctx, src); err != nil {
+		logger.Error(err, "unable to remove clone annotation from PVC")
+		return nil, err
+	}
+	return clone, nil
+}
+
+// cleanupOldClones deletes the clones that were preserved by previous syncs
+func (vh *VolumeHandler) cleanupOldClones(ctx context.Context, log logr.Logger,
+	current *v1.PersistentVolumeClaim) error {
+	pvcList := &v1.PersistentVolumeClaimList{}
+	err := vh.client.List(ctx, pvcList, client.InNamespace(vh.owner.GetNamespace()),
+		client.MatchingLabels{imageLabelKey: string(vh.owner.GetUID())})
+	if err != nil {
+		log.Error(err, "unable to list previous clones")
+		return err
+	}
+	for i := range pvcList.Items {
+		old := &pvcList.Items[i]
+		if old.Name == current.Name || !metav1.IsControlledBy(old, vh.owner) {
+			continue
+		}
+		if err := vh.client.Delete(ctx, old); err != nil && !kerrors.IsNotFound(err) {
+			log.Error(err, "unable to delete old clone", "PVC", old.Name)
+			return err
+		}
+		log.Info("old clone deleted", "PVC", old.Name)
+	}
+	return nil
+}
+
+// storageClassFor returns the StorageClass of the PVC (or the default
+// StorageClass if the PVC doesn't name one), or nil if there is none
+func storageClassFor(ctx context.Context, c client.Client,
+	pvc *v1.PersistentVolumeClaim) (*storagev1.StorageClass, error) {
+	if pvc.Spec.StorageClassName != nil {
+		if *pvc.Spec.StorageClassName == "" {
+			return nil, nil
+		}
+		sc := &storagev1.StorageClass{}
+		err := c.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, sc)
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return sc, err
+	}
+	scList := &storagev1.StorageClassList{}
+	if err := c.List(ctx, scList); err != nil {
+		return nil, err
+	}
+	for i := range scList.Items {
+		if scList.Items[i].Annotations[defaultStorageClassAnnotation] == "true" {
+			return &scList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -76,6 +76,7 @@ func FromSource(s *volsyncv1alpha1.ReplicationSourceVolumeOptions) VHOption {
 		vh.storageClassName = s.StorageClassName
 		vh.accessModes = s.AccessModes
 		vh.volumeSnapshotClassName = s.VolumeSnapshotClassName
+		vh.snapshotTimeout = SnapshotTimeout(s.SnapshotTimeoutSeconds)
 	}
 }
 
@@ -87,7 +88,17 @@ func FromDestination(d *volsyncv1alpha1.ReplicationDestinationVolumeOptions) VHO
 		vh.capacity = d.Capacity
 		vh.storageClassName = d.StorageClassName
 		vh.accessModes = d.AccessModes
+		vh.volumeMode = d.VolumeMode
 		vh.volumeSnapshotClassName = d.VolumeSnapshotClassName
+		vh.snapshotTimeout = SnapshotTimeout(d.SnapshotTimeoutSeconds)
+	}
+}
+
+// SelectedCopyMethod specifies where the copy method that is chosen when the
+// copyMethod is Auto is recorded. A previously recorded method is reused.
+func SelectedCopyMethod(cm *volsyncv1alpha1.CopyMethodType) VHOption {
+	return func(vh *VolumeHandler) {
+		vh.selectedCopyMethod = cm
 	}
 }
 
@@ -97,6 +108,12 @@ func AccessModes(am []v1.PersistentVolumeAccessMode) VHOption {
 	}
 }
 
+func VolumeMode(vm *v1.PersistentVolumeMode) VHOption {
+	return func(vh *VolumeHandler) {
+		vh.volumeMode = vm
+	}
+}
+
 func CopyMethod(cm volsyncv1alpha1.CopyMethodType) VHOption {
 	return func(vh *VolumeHandler) {
 		vh.copyMethod = cm
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package volumehandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// ErrSnapshotFailed indicates that a VolumeSnapshot reported an error
+	ErrSnapshotFailed = errors.New("volume snapshot failed")
+	// ErrSnapshotTimeout indicates that a VolumeSnapshot did not become ready
+	// to use within the configured timeout
+	ErrSnapshotTimeout = errors.New("timed out waiting for volume snapshot")
+)
+
+// SnapshotError describes a VolumeSnapshot that failed or that did not become
+// ready in time. It wraps either ErrSnapshotFailed or ErrSnapshotTimeout.
+type SnapshotError struct {
+	// Name of the VolumeSnapshot
+	Name string
+	// Message is the error reported by the snapshot, if any
+	Message string
+	// Err is the reason the snapshot was abandoned
+	Err error
+}
+
+func (e *SnapshotError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%v: %s", e.Err, e.Name)
+	}
+	return fmt.Sprintf("%v: %s: %s", e.Err, e.Name, e.Message)
+}
+
+func (e *SnapshotError) Unwrap() error {
+	return e.Err
+}
+
+// CheckSnapshot returns true if the VolumeSnapshot is bound and ready to use.
+// A snapshot that reports an error, or that isn't ready within timeout (if
+// non-zero), is deleted so that it will be recreated, and a SnapshotError is
+// returned.
+func CheckSnapshot(ctx context.Context, c client.Client, log logr.Logger,
+	snap *snapv1.VolumeSnapshot, timeout time.Duration) (bool, error) {
+	if !snap.DeletionTimestamp.IsZero() {
+		log.V(1).Info("snapshot is being deleted-- need to wait")
+		return false, nil
+	}
+	if snap.Status != nil && snap.Status.BoundVolumeSnapshotContentName != nil &&
+		snap.Status.ReadyToUse != nil && *snap.Status.ReadyToUse {
+		return true, nil
+	}
+
+	var snapErr *SnapshotError
+	if snap.Status != nil && snap.Status.Error != nil {
+		snapErr = &SnapshotError{Name: snap.Name, Err: ErrSnapshotFailed}
+		if snap.Status.Error.Message != nil {
+			snapErr.Message = *snap.Status.Error.Message
+		}
+	} else if timeout > 0 && !snap.CreationTimestamp.IsZero() &&
+		time.Since(snap.CreationTimestamp.Time) > timeout {
+		snapErr = &SnapshotError{Name: snap.Name, Err: ErrSnapshotTimeout}
+	}
+	if snapErr == nil {
+		log.V(1).Info("waiting for snapshot to be ready")
+		return false, nil
+	}
+
+	log.Error(snapErr, "deleting snapshot so it can be retried")
+	if err := c.Delete(ctx, snap); err != nil && !kerrors.IsNotFound(err) {
+		log.Error(err, "unable to delete snapshot")
+		return false, err
+	}
+	return false, snapErr
+}
+
+// SnapshotTimeout converts the snapshotTimeoutSeconds of the volume options to
+// a Duration, where 0 means there is no timeout
+func SnapshotTimeout(seconds *int32) time.Duration {
+	if seconds == nil {
+		return 0
+	}
+	return time.Duration(*seconds) * time.Second
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package volumehandler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/backube/volsync/controllers/utils"
+)
+
+var _ = Describe("Snapshot readiness", func() {
+	var ctx = context.TODO()
+	var ns *v1.Namespace
+	var snap *snapv1.VolumeSnapshot
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+
+	BeforeEach(func() {
+		ns = &v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "vh-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		pvcName := "mypvc"
+		snap = &snapv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mysnap",
+				Namespace: ns.Name,
+			},
+			Spec: snapv1.VolumeSnapshotSpec{
+				Source: snapv1.VolumeSnapshotSource{
+					PersistentVolumeClaimName: &pvcName,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, snap)).To(Succeed())
+	})
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+	})
+
+	expectDeleted := func() {
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, utils.NameFor(snap), &snapv1.VolumeSnapshot{})
+			return kerrors.IsNotFound(err)
+		}, maxWait, interval).Should(BeTrue())
+	}
+
+	It("waits until a bound snapshot is ready to use", func() {
+		boundTo := "foo"
+		ready := false
+		snap.Status = &snapv1.VolumeSnapshotStatus{
+			BoundVolumeSnapshotContentName: &boundTo,
+			ReadyToUse:                     &ready,
+		}
+		Expect(k8sClient.Status().Update(ctx, snap)).To(Succeed())
+		Expect(CheckSnapshot(ctx, k8sClient, logger, snap, 0)).To(BeFalse())
+
+		ready = true
+		Expect(k8sClient.Status().Update(ctx, snap)).To(Succeed())
+		Expect(CheckSnapshot(ctx, k8sClient, logger, snap, 0)).To(BeTrue())
+	})
+	It("deletes a snapshot that reports an error", func() {
+		message := "csi driver failed"
+		snap.Status = &snapv1.VolumeSnapshotStatus{
+			Error: &snapv1.VolumeSnapshotError{Message: &message},
+		}
+		Expect(k8sClient.Status().Update(ctx, snap)).To(Succeed())
+		ready, err := CheckSnapshot(ctx, k8sClient, logger, snap, 0)
+		Expect(ready).To(BeFalse())
+		Expect(errors.Is(err, ErrSnapshotFailed)).To(BeTrue())
+		var snapErr *SnapshotError
+		Expect(errors.As(err, &snapErr)).To(BeTrue())
+		Expect(snapErr.Name).To(Equal(snap.Name))
+		Expect(snapErr.Message).To(Equal(message))
+		expectDeleted()
+	})
+	It("deletes a snapshot that isn't ready within the timeout", func() {
+		ready, err := CheckSnapshot(ctx, k8sClient, logger, snap, time.Nanosecond)
+		Expect(ready).To(BeFalse())
+		Expect(errors.Is(err, ErrSnapshotTimeout)).To(BeTrue())
+		expectDeleted()
+	})
+})
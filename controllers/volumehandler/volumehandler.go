@@ -24,7 +24,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -50,7 +50,11 @@ type VolumeHandler struct {
 	capacity                *resource.Quantity
 	storageClassName        *string
 	accessModes             []v1.PersistentVolumeAccessMode
+	volumeMode              *v1.PersistentVolumeMode
 	volumeSnapshotClassName *string
+	snapshotTimeout         time.Duration
+	// Where the copy method chosen by CopyMethodAuto is recorded
+	selectedCopyMethod *volsyncv1alpha1.CopyMethodType
 }
 
 // EnsurePVCFromSrc ensures the presence of a PVC that is based on the provided
@@ -65,20 +69,23 @@ func (vh *VolumeHandler) EnsurePVCFromSrc(ctx context.Context, log logr.Logger,
 	case volsyncv1alpha1.CopyMethodClone:
 		return vh.ensureClone(ctx, log, src, name, isTemporary)
 	case volsyncv1alpha1.CopyMethodSnapshot:
-		snap, err := vh.ensureSnapshot(ctx, log, src, name, isTemporary)
+		snap, err := vh.ensureSnapshot(ctx, log, src, name, isTemporary, vh.snapshotTimeout)
 		if snap == nil || err != nil {
 			return nil, err
 		}
 		return vh.pvcFromSnapshot(ctx, log, snap, src, name, isTemporary)
+	case volsyncv1alpha1.CopyMethodAuto:
+		return vh.ensureAuto(ctx, log, src, name, isTemporary)
 	default:
-		return nil, fmt.Errorf("unsupported copyMethod: %v -- must be None, Clone, or Snapshot", vh.copyMethod)
+		return nil, fmt.Errorf("unsupported copyMethod: %v -- must be None, Clone, Snapshot, or Auto", vh.copyMethod)
 	}
 }
 
 // EnsureImage ensures the presence of a representation of the provided src
 // PVC. It is generated based on the VolumeHandler's configuration and could be
 // of type PersistentVolumeClaim or VolumeSnapshot. It may even be the same PVC
-// as src.
+// as src. When cloning, the clones preserved by previous calls are removed
+// once the new one has been provisioned.
 func (vh *VolumeHandler) EnsureImage(ctx context.Context, log logr.Logger,
 	src *v1.PersistentVolumeClaim) (*v1.TypedLocalObjectReference, error) {
 	switch vh.copyMethod { //nolint: exhaustive
@@ -98,13 +105,23 @@ func (vh *VolumeHandler) EnsureImage(ctx context.Context, log logr.Logger,
 			Kind:     snap.Kind,
 			Name:     snap.Name,
 		}, nil
+	case volsyncv1alpha1.CopyMethodClone:
+		clone, err := vh.ensureImageClone(ctx, log, src)
+		if clone == nil || err != nil {
+			return nil, err
+		}
+		return &v1.TypedLocalObjectReference{
+			APIGroup: &v1.SchemeGroupVersion.Group,
+			Kind:     "PersistentVolumeClaim",
+			Name:     clone.Name,
+		}, nil
 	default:
-		return nil, fmt.Errorf("unsupported copyMethod: %v -- must be None, or Snapshot", vh.copyMethod)
+		return nil, fmt.Errorf("unsupported copyMethod: %v -- must be None, Clone, or Snapshot", vh.copyMethod)
 	}
 }
 
 func (vh *VolumeHandler) EnsureNewPVC(ctx context.Context, log logr.Logger,
-	name string) (*v1.PersistentVolumeClaim, error) {
+	name string, isTemporary bool) (*v1.PersistentVolumeClaim, error) {
 	logger := log.WithValues("PVC", name)
 
 	// Ensure required configuration parameters have been provided in order to
@@ -132,10 +149,16 @@ func (vh *VolumeHandler) EnsureNewPVC(ctx context.Context, log logr.Logger,
 			logger.Error(err, "unable to set controller reference")
 			return err
 		}
+		if isTemporary {
+			utils.MarkForCleanup(vh.owner, pvc)
+		}
 		if pvc.CreationTimestamp.IsZero() { // set immutable fields
 			pvc.Spec.AccessModes = vh.accessModes
 			pvc.Spec.StorageClassName = vh.storageClassName
 			volumeMode := v1.PersistentVolumeFilesystem
+			if vh.volumeMode != nil {
+				volumeMode = *vh.volumeMode
+			}
 			pvc.Spec.VolumeMode = &volumeMode
 		}
 
@@ -207,9 +230,9 @@ func (vh *VolumeHandler) ensureImageSnapshot(ctx context.Context, log logr.Logge
 	}
 	logger.V(1).Info("Snapshot reconciled", "operation", op)
 
-	// We only continue reconciling if the snapshot has been bound & not deleted
-	if snap.Status == nil || snap.Status.BoundVolumeSnapshotContentName == nil || !snap.DeletionTimestamp.IsZero() {
-		return nil, nil
+	// We only continue reconciling if the snapshot is ready & not deleted
+	if ready, err := CheckSnapshot(ctx, vh.client, logger, snap, vh.snapshotTimeout); !ready || err != nil {
+		return nil, err
 	}
 
 	return snap, nil
@@ -253,6 +276,7 @@ func (vh *VolumeHandler) ensureClone(ctx context.Context, log logr.Logger,
 			} else {
 				clone.Spec.AccessModes = src.Spec.AccessModes
 			}
+			clone.Spec.VolumeMode = src.Spec.VolumeMode
 			clone.Spec.DataSource = &v1.TypedLocalObjectReference{
 				APIGroup: nil,
 				Kind:     "PersistentVolumeClaim",
@@ -274,7 +298,8 @@ func (vh *VolumeHandler) ensureClone(ctx context.Context, log logr.Logger,
 }
 
 func (vh *VolumeHandler) ensureSnapshot(ctx context.Context, log logr.Logger,
-	src *v1.PersistentVolumeClaim, name string, isTemporary bool) (*snapv1.VolumeSnapshot, error) {
+	src *v1.PersistentVolumeClaim, name string, isTemporary bool,
+	timeout time.Duration) (*snapv1.VolumeSnapshot, error) {
 	snap := &snapv1.VolumeSnapshot{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -301,13 +326,8 @@ func (vh *VolumeHandler) ensureSnapshot(ctx context.Context, log logr.Logger,
 		logger.Error(err, "reconcile failed")
 		return nil, err
 	}
-	if !snap.DeletionTimestamp.IsZero() {
-		logger.V(1).Info("snap is being deleted-- need to wait")
-		return nil, nil
-	}
-	if snap.Status == nil || snap.Status.BoundVolumeSnapshotContentName == nil {
-		logger.V(1).Info("waiting for snapshot to be bound")
-		return nil, nil
+	if ready, err := CheckSnapshot(ctx, vh.client, logger, snap, timeout); !ready || err != nil {
+		return nil, err
 	}
 	logger.V(1).Info("temporary snapshot reconciled", "operation", op)
 	return snap, nil
@@ -356,6 +376,7 @@ func (vh *VolumeHandler) pvcFromSnapshot(ctx context.Context, log logr.Logger,
 			} else {
 				pvc.Spec.AccessModes = original.Spec.AccessModes
 			}
+			pvc.Spec.VolumeMode = original.Spec.VolumeMode
 			pvc.Spec.DataSource = &v1.TypedLocalObjectReference{
 				APIGroup: &snapv1.SchemeGroupVersion.Group,
 				Kind:     "VolumeSnapshot",
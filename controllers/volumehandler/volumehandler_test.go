@@ -20,13 +20,16 @@ package volumehandler
 import (
 	"context"
 
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
@@ -106,7 +109,7 @@ var _ = Describe("Volumehandler", func() {
 				Expect(vh).ToNot(BeNil())
 
 				pvcName := "thepvc"
-				new, err := vh.EnsureNewPVC(context.TODO(), logger, pvcName)
+				new, err := vh.EnsureNewPVC(context.TODO(), logger, pvcName, false)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(new).ToNot(BeNil())
 				Expect(*new.Spec.StorageClassName).To(Equal(customSC))
@@ -115,6 +118,29 @@ var _ = Describe("Volumehandler", func() {
 			})
 		})
 
+		When("volumeMode is Block", func() {
+			capacity := resource.MustParse("3Gi")
+			BeforeEach(func() {
+				rd.Spec.Rsync.Capacity = &capacity
+				block := v1.PersistentVolumeBlock
+				rd.Spec.Rsync.VolumeMode = &block
+			})
+
+			It("provisions a block PVC", func() {
+				vh, err := NewVolumeHandler(
+					WithClient(k8sClient),
+					WithOwner(rd),
+					FromDestination(&rd.Spec.Rsync.ReplicationDestinationVolumeOptions),
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				new, err := vh.EnsureNewPVC(context.TODO(), logger, "blockpvc", false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(new).ToNot(BeNil())
+				Expect(*new.Spec.VolumeMode).To(Equal(v1.PersistentVolumeBlock))
+			})
+		})
+
 		When("CopyMethod is None", func() {
 			BeforeEach(func() {
 				rd.Spec.Rsync.CopyMethod = volsyncv1alpha1.CopyMethodNone
@@ -213,9 +239,11 @@ var _ = Describe("Volumehandler", func() {
 				Eventually(func() error {
 					return k8sClient.Get(ctx, types.NamespacedName{Name: snapname, Namespace: ns.Name}, snap)
 				}, maxWait, interval).Should(Succeed())
+				ready := true
 				boundTo := "foo"
 				snap.Status = &snapv1.VolumeSnapshotStatus{
 					BoundVolumeSnapshotContentName: &boundTo,
+					ReadyToUse:                     &ready,
 				}
 				Expect(k8sClient.Status().Update(ctx, snap)).To(Succeed())
 
@@ -232,6 +260,144 @@ var _ = Describe("Volumehandler", func() {
 				Expect(*tlor.APIGroup).To(Equal(snapv1.SchemeGroupVersion.Group))
 			})
 		})
+
+		When("CopyMethod is Clone", func() {
+			BeforeEach(func() {
+				rd.Spec.Rsync.CopyMethod = volsyncv1alpha1.CopyMethodClone
+			})
+
+			It("the preserved image is a clone of the PVC, and old clones are removed", func() {
+				vh, err := NewVolumeHandler(
+					WithClient(k8sClient),
+					WithOwner(rd),
+					FromDestination(&rd.Spec.Rsync.ReplicationDestinationVolumeOptions),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vh).ToNot(BeNil())
+
+				pvc := &v1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mypvc",
+						Namespace: ns.Name,
+					},
+					Spec: v1.PersistentVolumeClaimSpec{
+						AccessModes: []v1.PersistentVolumeAccessMode{
+							v1.ReadWriteMany,
+						},
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{
+								"storage": resource.MustParse("2Gi"),
+							},
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, pvc)).To(Succeed())
+
+				// A clone preserved by a previous sync
+				oldClone := &v1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "oldclone",
+						Namespace: ns.Name,
+						Labels:    map[string]string{imageLabelKey: string(rd.UID)},
+					},
+					Spec: pvc.Spec,
+				}
+				Expect(ctrlutil.SetControllerReference(rd, oldClone, k8sClient.Scheme())).To(Succeed())
+				Expect(k8sClient.Create(ctx, oldClone)).To(Succeed())
+
+				tlor, err := vh.EnsureImage(ctx, logger, pvc)
+				Expect(err).NotTo(HaveOccurred())
+				// Since the clone is not bound
+				Expect(tlor).To(BeNil())
+
+				// Grab the clone and make it look bound
+				Expect(k8sClient.Get(ctx, utils.NameFor(pvc), pvc)).To(Succeed())
+				cloneName := pvc.Annotations[cloneAnnotation]
+				clone := &v1.PersistentVolumeClaim{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: cloneName, Namespace: ns.Name}, clone)).To(Succeed())
+				Expect(clone.Spec.DataSource.Name).To(Equal(pvc.Name))
+				Expect(clone.Spec.AccessModes).To(Equal(rd.Spec.Rsync.AccessModes))
+				clone.Status.Phase = v1.ClaimBound
+				Expect(k8sClient.Status().Update(ctx, clone)).To(Succeed())
+
+				tlor, err = vh.EnsureImage(ctx, logger, pvc)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tlor).NotTo(BeNil())
+				Expect(tlor.Kind).To(Equal("PersistentVolumeClaim"))
+				Expect(tlor.Name).To(Equal(cloneName))
+				Expect(*tlor.APIGroup).To(Equal(v1.SchemeGroupVersion.Group))
+
+				// The next sync will use a new clone
+				Expect(k8sClient.Get(ctx, utils.NameFor(pvc), pvc)).To(Succeed())
+				Expect(pvc.Annotations).NotTo(HaveKey(cloneAnnotation))
+				// The previous clone is removed
+				Eventually(func() bool {
+					err := k8sClient.Get(ctx, utils.NameFor(oldClone), oldClone)
+					return kerrors.IsNotFound(err) || !oldClone.DeletionTimestamp.IsZero()
+				}, maxWait, interval).Should(BeTrue())
+			})
+
+			It("clones with WaitForFirstConsumer binding are provisioned on the node of the PVC", func() {
+				wffc := storagev1.VolumeBindingWaitForFirstConsumer
+				sc := &storagev1.StorageClass{
+					ObjectMeta: metav1.ObjectMeta{
+						GenerateName: "wffc-",
+					},
+					Provisioner:       "wffc.csi.example.com",
+					VolumeBindingMode: &wffc,
+				}
+				Expect(k8sClient.Create(ctx, sc)).To(Succeed())
+				defer func() { Expect(k8sClient.Delete(ctx, sc)).To(Succeed()) }()
+
+				vh, err := NewVolumeHandler(
+					WithClient(k8sClient),
+					WithOwner(rd),
+					FromDestination(&rd.Spec.Rsync.ReplicationDestinationVolumeOptions),
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				pvc := &v1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "wffcpvc",
+						Namespace:   ns.Name,
+						Annotations: map[string]string{selectedNodeAnnotation: "node1"},
+					},
+					Spec: v1.PersistentVolumeClaimSpec{
+						AccessModes: []v1.PersistentVolumeAccessMode{
+							v1.ReadWriteOnce,
+						},
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{
+								"storage": resource.MustParse("2Gi"),
+							},
+						},
+						StorageClassName: &sc.Name,
+					},
+				}
+				Expect(k8sClient.Create(ctx, pvc)).To(Succeed())
+
+				tlor, err := vh.EnsureImage(ctx, logger, pvc)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tlor).To(BeNil())
+				// The clone isn't the image until it is bound
+				tlor, err = vh.EnsureImage(ctx, logger, pvc)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tlor).To(BeNil())
+
+				Expect(k8sClient.Get(ctx, utils.NameFor(pvc), pvc)).To(Succeed())
+				clone := &v1.PersistentVolumeClaim{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: pvc.Annotations[cloneAnnotation],
+					Namespace: ns.Name}, clone)).To(Succeed())
+				Expect(clone.Annotations).To(HaveKeyWithValue(selectedNodeAnnotation, "node1"))
+				clone.Status.Phase = v1.ClaimBound
+				Expect(k8sClient.Status().Update(ctx, clone)).To(Succeed())
+
+				tlor, err = vh.EnsureImage(ctx, logger, pvc)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tlor).NotTo(BeNil())
+				Expect(tlor.Name).To(Equal(clone.Name))
+			})
+		})
 	})
 
 	Context("A VolumeHandler (from source)", func() {
@@ -314,6 +480,25 @@ var _ = Describe("Volumehandler", func() {
 				Expect(new.Spec.Resources.Requests.Storage()).To(Equal(src.Spec.Resources.Requests.Storage()))
 				Expect(new.Spec.AccessModes).To(Equal(src.Spec.AccessModes))
 			})
+			When("the source is a block volume", func() {
+				BeforeEach(func() {
+					block := v1.PersistentVolumeBlock
+					src.Spec.VolumeMode = &block
+				})
+				It("the clone is also a block volume", func() {
+					vh, err := NewVolumeHandler(
+						WithClient(k8sClient),
+						WithOwner(rs),
+						FromSource(&rs.Spec.Rsync.ReplicationSourceVolumeOptions),
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					new, err := vh.EnsurePVCFromSrc(ctx, logger, src, "newpvc", true)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(new).ToNot(BeNil())
+					Expect(*new.Spec.VolumeMode).To(Equal(v1.PersistentVolumeBlock))
+				})
+			})
 			When("options are overridden", func() {
 				newSC := "thenewsc"
 				newCap := resource.MustParse("9Gi")
@@ -368,9 +553,11 @@ var _ = Describe("Volumehandler", func() {
 				Eventually(func() error {
 					return k8sClient.Get(ctx, types.NamespacedName{Name: "newpvc", Namespace: ns.Name}, snap)
 				}, maxWait, interval).Should(Succeed())
+				ready := true
 				boundTo := "bar"
 				snap.Status = &snapv1.VolumeSnapshotStatus{
 					BoundVolumeSnapshotContentName: &boundTo,
+					ReadyToUse:                     &ready,
 				}
 				Expect(k8sClient.Status().Update(ctx, snap)).To(Succeed())
 				Expect(snap.Spec.VolumeSnapshotClassName).To(BeNil())
@@ -421,9 +608,11 @@ var _ = Describe("Volumehandler", func() {
 					Eventually(func() error {
 						return k8sClient.Get(ctx, types.NamespacedName{Name: "newpvc", Namespace: ns.Name}, snap)
 					}, maxWait, interval).Should(Succeed())
+					ready := true
 					boundTo := "foo2"
 					snap.Status = &snapv1.VolumeSnapshotStatus{
 						BoundVolumeSnapshotContentName: &boundTo,
+						ReadyToUse:                     &ready,
 					}
 					Expect(k8sClient.Status().Update(ctx, snap)).To(Succeed())
 					Expect(*snap.Spec.VolumeSnapshotClassName).To(Equal(newVSC))
@@ -0,0 +1,333 @@
+/*
+Copyright 2021 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/utils"
+)
+
+const (
+	// Annotation on a populated PVC that selects a specific image (by name)
+	// instead of the ReplicationDestination's latestImage
+	populateFromImageAnnotation = "volsync.backube/populate-from-image"
+	// Annotation set by the scheduler on PVCs with WaitForFirstConsumer
+	// binding once a Pod using them has been scheduled
+	selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+	// Prefix of the temporary PVCs that are provisioned from the image
+	populatorPrimePrefix = "volsync-prime-"
+)
+
+// VolumePopulatorReconciler populates PVCs whose dataSource refers to a
+// ReplicationDestination with the data of its latest image
+type VolumePopulatorReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;update;patch
+
+func (r *VolumePopulatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Log.WithValues("pvc", req.NamespacedName)
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Client.Get(ctx, req.NamespacedName, pvc); err != nil {
+		if !kerrors.IsNotFound(err) {
+			logger.Error(err, "Failed to get PVC")
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !isPopulatedByVolSync(pvc) || !pvc.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	_, err := r.populate(ctx, logger, pvc)
+	return ctrl.Result{}, err
+}
+
+// populate provisions a temporary ("prime") PVC from the selected image and,
+// once it has been bound, hands its PersistentVolume over to the populated
+// PVC. It returns true once the populated PVC has its volume and the prime
+// PVC has been removed.
+func (r *VolumePopulatorReconciler) populate(ctx context.Context, logger logr.Logger,
+	pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	prime := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      populatorPrimePrefix + string(pvc.UID),
+			Namespace: pvc.Namespace,
+		},
+	}
+	l := logger.WithValues("prime", prime.Name)
+
+	// Once the PV has been handed over, the prime PVC is no longer needed
+	if pvc.Spec.VolumeName != "" {
+		if err := r.Client.Delete(ctx, prime); err != nil && !kerrors.IsNotFound(err) {
+			l.Error(err, "unable to delete prime PVC")
+			return false, err
+		}
+		return true, nil
+	}
+
+	image, err := r.imageFor(ctx, pvc)
+	if image == nil || err != nil {
+		return false, err
+	}
+
+	// With WaitForFirstConsumer binding, the volume must be provisioned on the
+	// node that the consumer was scheduled to
+	waitForConsumer, err := r.waitsForFirstConsumer(ctx, pvc)
+	if err != nil {
+		l.Error(err, "unable to determine volume binding mode")
+		return false, err
+	}
+	if waitForConsumer && pvc.Annotations[selectedNodeAnnotation] == "" {
+		l.V(1).Info("waiting for a consumer to be scheduled")
+		return false, nil
+	}
+
+	// A prime PVC stays Pending if its image is deleted before it has been
+	// provisioned, so it is replaced by one from the current image
+	err = r.Client.Get(ctx, utils.NameFor(prime), prime)
+	if err != nil && !kerrors.IsNotFound(err) {
+		l.Error(err, "unable to get prime PVC")
+		return false, err
+	}
+	if err == nil && prime.Spec.VolumeName == "" {
+		if !prime.DeletionTimestamp.IsZero() {
+			l.V(1).Info("prime PVC is being deleted-- need to wait")
+			return false, nil
+		}
+		exists, err := r.imageExists(ctx, prime.Namespace, prime.Spec.DataSource)
+		if err != nil {
+			l.Error(err, "unable to get prime PVC image")
+			return false, err
+		}
+		if !exists {
+			if err := r.Client.Delete(ctx, prime); err != nil && !kerrors.IsNotFound(err) {
+				l.Error(err, "unable to delete prime PVC")
+				return false, err
+			}
+			l.Info("image of prime PVC no longer exists-- recreating it", "image", prime.Spec.DataSource)
+			return false, nil
+		}
+	}
+
+	op, err := ctrlutil.CreateOrUpdate(ctx, r.Client, prime, func() error {
+		if err := ctrl.SetControllerReference(pvc, prime, r.Scheme); err != nil {
+			l.Error(err, "unable to set controller reference")
+			return err
+		}
+		if prime.CreationTimestamp.IsZero() {
+			if node, ok := pvc.Annotations[selectedNodeAnnotation]; ok {
+				prime.Annotations = map[string]string{selectedNodeAnnotation: node}
+			}
+			prime.Spec = corev1.PersistentVolumeClaimSpec{
+				AccessModes:      pvc.Spec.AccessModes,
+				Resources:        pvc.Spec.Resources,
+				StorageClassName: pvc.Spec.StorageClassName,
+				VolumeMode:       pvc.Spec.VolumeMode,
+				DataSource:       image,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		l.Error(err, "reconcile failed")
+		return false, err
+	}
+	l.V(1).Info("prime PVC reconciled", "operation", op, "image", image)
+	if prime.Spec.VolumeName == "" {
+		l.V(1).Info("waiting for prime PVC to be bound")
+		return false, nil
+	}
+
+	// Hand the volume over to the populated PVC
+	pv := &corev1.PersistentVolume{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: prime.Spec.VolumeName}, pv); err != nil {
+		l.Error(err, "unable to get prime PV")
+		return false, err
+	}
+	if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.UID != pvc.UID {
+		pv.Spec.ClaimRef = &corev1.ObjectReference{
+			APIVersion:      "v1",
+			Kind:            "PersistentVolumeClaim",
+			Namespace:       pvc.Namespace,
+			Name:            pvc.Name,
+			UID:             pvc.UID,
+			ResourceVersion: pvc.ResourceVersion,
+		}
+		if err := r.Client.Update(ctx, pv); err != nil {
+			l.Error(err, "unable to rebind PV", "PV", pv.Name)
+			return false, err
+		}
+		l.Info("volume populated", "PV", pv.Name, "image", image)
+	}
+	// The PVC is bound to the PV by the PV controller
+	return false, nil
+}
+
+// imageFor returns the image to populate the PVC from, or nil if the
+// ReplicationDestination doesn't have one yet
+func (r *VolumePopulatorReconciler) imageFor(ctx context.Context,
+	pvc *corev1.PersistentVolumeClaim) (*corev1.TypedLocalObjectReference, error) {
+	rd := &volsyncv1alpha1.ReplicationDestination{}
+	rdName := types.NamespacedName{Name: pvc.Spec.DataSource.Name, Namespace: pvc.Namespace}
+	if err := r.Client.Get(ctx, rdName, rd); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.Log.V(1).Info("waiting for ReplicationDestination", "replicationdestination", rdName)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if name, ok := pvc.Annotations[populateFromImageAnnotation]; ok {
+		// A specific image, which must be one that was created by the
+		// ReplicationDestination
+		snap := &snapv1.VolumeSnapshot{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: pvc.Namespace}, snap)
+		if err == nil && metav1.IsControlledBy(snap, rd) {
+			return &corev1.TypedLocalObjectReference{
+				APIGroup: &snapv1.SchemeGroupVersion.Group,
+				Kind:     "VolumeSnapshot",
+				Name:     snap.Name,
+			}, nil
+		}
+		if err != nil && !kerrors.IsNotFound(err) {
+			return nil, err
+		}
+		clone := &corev1.PersistentVolumeClaim{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: pvc.Namespace}, clone)
+		if err == nil && metav1.IsControlledBy(clone, rd) {
+			return &corev1.TypedLocalObjectReference{
+				Kind: "PersistentVolumeClaim",
+				Name: clone.Name,
+			}, nil
+		}
+		if err != nil && !kerrors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("image %s was not found for ReplicationDestination %s", name, rd.Name)
+	}
+
+	if rd.Status == nil || rd.Status.LatestImage == nil {
+		r.Log.V(1).Info("waiting for an image", "replicationdestination", rdName)
+		return nil, nil
+	}
+	image := rd.Status.LatestImage.DeepCopy()
+	if image.APIGroup != nil && *image.APIGroup == "" {
+		// PVC data sources must not specify the core group
+		image.APIGroup = nil
+	}
+	return image, nil
+}
+
+// imageExists returns true if the VolumeSnapshot or PVC referenced as an
+// image is present
+func (r *VolumePopulatorReconciler) imageExists(ctx context.Context, namespace string,
+	image *corev1.TypedLocalObjectReference) (bool, error) {
+	if image == nil {
+		return false, nil
+	}
+	var obj client.Object
+	switch image.Kind {
+	case "VolumeSnapshot":
+		obj = &snapv1.VolumeSnapshot{}
+	case "PersistentVolumeClaim":
+		obj = &corev1.PersistentVolumeClaim{}
+	default:
+		// Not one of ours, so there's nothing to check
+		return true, nil
+	}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: image.Name, Namespace: namespace}, obj)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *VolumePopulatorReconciler) waitsForFirstConsumer(ctx context.Context,
+	pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	sc := &storagev1.StorageClass{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, sc); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer, nil
+}
+
+// isPopulatedByVolSync returns true if the PVC's data source is a
+// ReplicationDestination. Clusters that support dataSourceRef mirror it into
+// dataSource.
+func isPopulatedByVolSync(pvc *corev1.PersistentVolumeClaim) bool {
+	ds := pvc.Spec.DataSource
+	return ds != nil && ds.APIGroup != nil && *ds.APIGroup == volsyncv1alpha1.GroupVersion.Group &&
+		ds.Kind == "ReplicationDestination"
+}
+
+// pvcsForDestination maps a ReplicationDestination to the PVCs that are
+// populated from it
+func (r *VolumePopulatorReconciler) pvcsForDestination(obj client.Object) []reconcile.Request {
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	if err := r.Client.List(context.Background(), pvcList, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "unable to list PVCs")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		if isPopulatedByVolSync(pvc) && pvc.Spec.DataSource.Name == obj.GetName() && pvc.Spec.VolumeName == "" {
+			requests = append(requests, reconcile.Request{NamespacedName: utils.NameFor(pvc)})
+		}
+	}
+	return requests
+}
+
+func (r *VolumePopulatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("volumepopulator").
+		For(&corev1.PersistentVolumeClaim{}, builder.WithPredicates(predicate.NewPredicateFuncs(
+			func(obj client.Object) bool {
+				pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+				return ok && isPopulatedByVolSync(pvc)
+			}))).
+		Owns(&corev1.PersistentVolumeClaim{}).
+		Watches(&source.Kind{Type: &volsyncv1alpha1.ReplicationDestination{}},
+			handler.EnqueueRequestsFromMapFunc(r.pvcsForDestination)).
+		Complete(r)
+}
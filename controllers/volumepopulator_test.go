@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"context"
+
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+)
+
+var _ = Describe("Volume populator", func() {
+	var ctx = context.Background()
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+	var namespace *corev1.Namespace
+	var rd *volsyncv1alpha1.ReplicationDestination
+	var pvc *corev1.PersistentVolumeClaim
+	var r *VolumePopulatorReconciler
+	snapGroup := snapv1.SchemeGroupVersion.Group
+
+	getPrime := func() (*corev1.PersistentVolumeClaim, error) {
+		prime := &corev1.PersistentVolumeClaim{}
+		err := k8sClient.Get(ctx, types.NamespacedName{
+			Name:      populatorPrimePrefix + string(pvc.UID),
+			Namespace: namespace.Name,
+		}, prime)
+		return prime, err
+	}
+
+	BeforeEach(func() {
+		namespace = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "volsync-test-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+		r = &VolumePopulatorReconciler{
+			Client: k8sClient,
+			Log:    logger,
+			Scheme: k8sClient.Scheme(),
+		}
+		rd = &volsyncv1alpha1.ReplicationDestination{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rd",
+				Namespace: namespace.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, rd)).To(Succeed())
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app-data",
+				Namespace: namespace.Name,
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("1Gi"),
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pvc)).To(Succeed())
+		// The API server only keeps a non-core dataSource if the
+		// AnyVolumeDataSource feature is enabled, so it's set locally
+		group := volsyncv1alpha1.GroupVersion.Group
+		pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+			APIGroup: &group,
+			Kind:     "ReplicationDestination",
+			Name:     rd.Name,
+		}
+	})
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, namespace)).To(Succeed())
+	})
+
+	It("waits for the ReplicationDestination to have an image", func() {
+		Expect(r.populate(ctx, logger, pvc)).To(BeFalse())
+		_, err := getPrime()
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("populates the PVC from the latest image", func() {
+		rd.Status = &volsyncv1alpha1.ReplicationDestinationStatus{
+			LatestImage: &corev1.TypedLocalObjectReference{
+				APIGroup: &snapGroup,
+				Kind:     "VolumeSnapshot",
+				Name:     "snap1",
+			},
+		}
+		Expect(k8sClient.Status().Update(ctx, rd)).To(Succeed())
+		Eventually(func() error {
+			_, _ = r.populate(ctx, logger, pvc)
+			_, err := getPrime()
+			return err
+		}, maxWait, interval).Should(Succeed())
+
+		By("provisioning a prime PVC from the image")
+		prime, err := getPrime()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prime.Spec.DataSource.Name).To(Equal("snap1"))
+		Expect(*prime.Spec.DataSource.APIGroup).To(Equal(snapGroup))
+		Expect(prime.Spec.AccessModes).To(Equal(pvc.Spec.AccessModes))
+		Expect(prime.Spec.Resources).To(Equal(pvc.Spec.Resources))
+
+		By("handing the prime's volume to the PVC")
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "pv-",
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				AccessModes: prime.Spec.AccessModes,
+				Capacity: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("1Gi"),
+				},
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: "/tmp/pv"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pv)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, pv)).To(Succeed()) }()
+		prime.Spec.VolumeName = pv.Name
+		Expect(k8sClient.Update(ctx, prime)).To(Succeed())
+		Eventually(func() types.UID {
+			_, _ = r.populate(ctx, logger, pvc)
+			_ = k8sClient.Get(ctx, types.NamespacedName{Name: pv.Name}, pv)
+			if pv.Spec.ClaimRef == nil {
+				return ""
+			}
+			return pv.Spec.ClaimRef.UID
+		}, maxWait, interval).Should(Equal(pvc.UID))
+		Expect(pv.Spec.ClaimRef.Name).To(Equal(pvc.Name))
+
+		By("removing the prime once the PVC is bound")
+		pvc.Spec.VolumeName = pv.Name
+		Expect(r.populate(ctx, logger, pvc)).To(BeTrue())
+		Eventually(func() bool {
+			prime, err := getPrime()
+			return kerrors.IsNotFound(err) || !prime.DeletionTimestamp.IsZero()
+		}, maxWait, interval).Should(BeTrue())
+	})
+
+	It("replaces a pending prime PVC whose image has been deleted", func() {
+		snap := &snapv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "snap1",
+				Namespace: namespace.Name,
+			},
+			Spec: snapv1.VolumeSnapshotSpec{
+				Source: snapv1.VolumeSnapshotSource{
+					PersistentVolumeClaimName: &pvc.Name,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, snap)).To(Succeed())
+		rd.Status = &volsyncv1alpha1.ReplicationDestinationStatus{
+			LatestImage: &corev1.TypedLocalObjectReference{
+				APIGroup: &snapGroup,
+				Kind:     "VolumeSnapshot",
+				Name:     snap.Name,
+			},
+		}
+		Expect(k8sClient.Status().Update(ctx, rd)).To(Succeed())
+		Eventually(func() error {
+			_, _ = r.populate(ctx, logger, pvc)
+			_, err := getPrime()
+			return err
+		}, maxWait, interval).Should(Succeed())
+
+		By("keeping the prime while its image exists")
+		Expect(r.populate(ctx, logger, pvc)).To(BeFalse())
+		prime, err := getPrime()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prime.DeletionTimestamp.IsZero()).To(BeTrue())
+
+		By("deleting the prime once its image is gone")
+		Expect(k8sClient.Delete(ctx, snap)).To(Succeed())
+		Eventually(func() bool {
+			_, _ = r.populate(ctx, logger, pvc)
+			prime, err := getPrime()
+			return kerrors.IsNotFound(err) || !prime.DeletionTimestamp.IsZero()
+		}, maxWait, interval).Should(BeTrue())
+	})
+
+	It("only uses a specific image that belongs to the ReplicationDestination", func() {
+		pvc.Annotations = map[string]string{populateFromImageAnnotation: "other"}
+		snap := &snapv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other",
+				Namespace: namespace.Name,
+			},
+			Spec: snapv1.VolumeSnapshotSpec{
+				Source: snapv1.VolumeSnapshotSource{
+					PersistentVolumeClaimName: &pvc.Name,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, snap)).To(Succeed())
+		_, err := r.populate(ctx, logger, pvc)
+		Expect(err).To(MatchError(ContainSubstring("image other was not found")))
+	})
+
+	It("waits for a consumer with WaitForFirstConsumer binding", func() {
+		wffc := storagev1.VolumeBindingWaitForFirstConsumer
+		sc := &storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "wffc-",
+			},
+			Provisioner:       "example.com/csi",
+			VolumeBindingMode: &wffc,
+		}
+		Expect(k8sClient.Create(ctx, sc)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, sc)).To(Succeed()) }()
+		pvc.Spec.StorageClassName = &sc.Name
+		rd.Status = &volsyncv1alpha1.ReplicationDestinationStatus{
+			LatestImage: &corev1.TypedLocalObjectReference{
+				APIGroup: &snapGroup,
+				Kind:     "VolumeSnapshot",
+				Name:     "snap1",
+			},
+		}
+		Expect(k8sClient.Status().Update(ctx, rd)).To(Succeed())
+
+		Expect(r.populate(ctx, logger, pvc)).To(BeFalse())
+		_, err := getPrime()
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+
+		pvc.Annotations = map[string]string{selectedNodeAnnotation: "node1"}
+		Eventually(func() error {
+			_, _ = r.populate(ctx, logger, pvc)
+			_, err := getPrime()
+			return err
+		}, maxWait, interval).Should(Succeed())
+		prime, err := getPrime()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prime.Annotations).To(HaveKeyWithValue(selectedNodeAnnotation, "node1"))
+	})
+})
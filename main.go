@@ -22,12 +22,12 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
-	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
 	kruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -38,6 +38,7 @@ import (
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	"github.com/backube/volsync/controllers"
 	"github.com/backube/volsync/controllers/mover/restic"
+	"github.com/backube/volsync/controllers/mover/rsynctls"
 	"github.com/backube/volsync/controllers/utils"
 	//+kubebuilder:scaffold:imports
 )
@@ -50,7 +51,6 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
-	utilruntime.Must(snapv1.AddToScheme(scheme))
 	utilruntime.Must(volsyncv1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
@@ -59,10 +59,14 @@ func init() {
 func main() {
 	// Register the data movers
 	restic.Register()
+	rsynctls.Register()
 
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var gcInterval time.Duration
+	var gcGracePeriod time.Duration
+	var gcDryRun bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -74,6 +78,12 @@ func main() {
 		controllers.DefaultRsyncContainerImage, "The container image for the rsync data mover")
 	flag.StringVar(&utils.SCCName, "scc-name",
 		utils.DefaultSCCName, "The name of the volsync security context constraint")
+	flag.DurationVar(&gcInterval, "gc-interval", 10*time.Minute,
+		"How often to remove stale temporary objects. Set to 0 to disable.")
+	flag.DurationVar(&gcGracePeriod, "gc-grace-period", time.Hour,
+		"How long a temporary object is kept before it may be removed as stale")
+	flag.BoolVar(&gcDryRun, "gc-dry-run", false,
+		"Log the stale temporary objects instead of removing them")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -88,7 +98,25 @@ func main() {
 	setupLog.Info(fmt.Sprintf("Rclone container: %s", controllers.RcloneContainerImage))
 	setupLog.Info(fmt.Sprintf("Rsync container: %s", controllers.RsyncContainerImage))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	cfg := ctrl.GetConfigOrDie()
+	// The snapshot types are registered based on the API version that the
+	// cluster serves
+	snapVersion, err := utils.DetectSnapshotVersion(cfg)
+	if err != nil {
+		setupLog.Error(err, "unable to detect VolumeSnapshot API version")
+		os.Exit(1)
+	}
+	setupLog.Info(fmt.Sprintf("VolumeSnapshot API: %s", snapVersion))
+	utilruntime.Must(utils.AddSnapshotToScheme(scheme, snapVersion))
+	// The movers are only granted use of the SCC on clusters that have them
+	utils.SCCAvailable, err = utils.DetectSCC(cfg)
+	if err != nil {
+		setupLog.Error(err, "unable to detect SecurityContextConstraints support")
+		os.Exit(1)
+	}
+	setupLog.Info(fmt.Sprintf("SCC support: %t", utils.SCCAvailable))
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
@@ -117,8 +145,29 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "ReplicationDestination")
 		os.Exit(1)
 	}
+	if err = (&controllers.VolumePopulatorReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("VolumePopulator"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VolumePopulator")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
+	if gcInterval > 0 {
+		if err = mgr.Add(&controllers.OrphanCollector{
+			Client:      mgr.GetClient(),
+			Log:         ctrl.Log.WithName("controllers").WithName("OrphanCollector"),
+			Interval:    gcInterval,
+			GracePeriod: gcGracePeriod,
+			DryRun:      gcDryRun,
+		}); err != nil {
+			setupLog.Error(err, "unable to add orphan collector")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
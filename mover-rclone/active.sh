@@ -17,19 +17,133 @@ function error {
 [[ -n "${DIRECTION}" ]] || error 1 "DIRECTION must be defined"
 
 RCLONE_FLAGS=(--checksum --one-file-system --create-empty-src-dirs --progress --stats-one-line-date --stats 20s --transfers 10)
+REMOTE="${RCLONE_CONFIG_SECTION}:${RCLONE_DEST_PATH}"
+
+# In Backup mode, previous versions of files are kept on the remote in
+# timestamped subdirectories of VERSIONS_DIR, which is never synchronized
+VERSIONS_DIR=".volsync-versions"
+RCLONE_FLAGS+=(--filter "- /${VERSIONS_DIR}/**")
+
+# Ownership, permissions, and ACLs of the files are captured into
+# PERMISSIONS_FILE, outside of the data, and stored on the remote in
+# METADATA_DIR, which is also never synchronized
+METADATA_DIR=".volsync-metadata"
+RCLONE_FLAGS+=(--filter "- /${METADATA_DIR}/**")
+PERMISSIONS_FILE="/tmp/permissions.facl"
+PERMISSIONS_REMOTE="${REMOTE}/${METADATA_DIR}/permissions.facl"
+
+# Reports a problem restoring permissions to the operator via the termination
+# message without failing the synchronization
+function report_permissions_error {
+    echo "error: unable to restore permissions: $1"
+    echo -n "permissions: $1" | head -c 3000 > /dev/termination-log
+}
+
+# Removes all but the newest RCLONE_BACKUP_RETENTION directories of previous
+# versions
+function prune_versions {
+    local keep="${RCLONE_BACKUP_RETENTION:-7}"
+    local versions="${REMOTE}/${VERSIONS_DIR}"
+    { rclone lsf --dirs-only "${versions}" 2> /dev/null || true; } | sort -r | tail -n +$(( keep + 1 )) |
+        while read -r dir; do
+            echo "Removing previous versions in ${dir}"
+            rclone purge "${versions}/${dir}"
+        done
+}
+
+# Propagates changes in both directions between MOUNT_PATH and the remote. The
+# listings from the previous run are kept in BISYNC_WORKDIR, and the number of
+# files that changed on both sides is reported via the termination message.
+function bisync {
+    [[ -n "${BISYNC_WORKDIR}" ]] || error 1 "BISYNC_WORKDIR must be defined"
+    local flags=(--workdir "${BISYNC_WORKDIR}" --resilient --recover)
+    case "${BISYNC_CONFLICT_RESOLUTION:-NewerWins}" in
+    NewerWins)
+        flags+=(--conflict-resolve newer --conflict-loser delete)
+        ;;
+    KeepBoth)
+        flags+=(--conflict-resolve none --conflict-loser num)
+        ;;
+    SourceWins)
+        flags+=(--conflict-resolve path1 --conflict-loser delete)
+        ;;
+    *)
+        error 1 "unknown value for BISYNC_CONFLICT_RESOLUTION: ${BISYNC_CONFLICT_RESOLUTION}"
+        ;;
+    esac
+    # Without listings from a previous run, the initial state must be
+    # established from both sides
+    if ! compgen -G "${BISYNC_WORKDIR}/*.lst" > /dev/null; then
+        echo "No previous bisync state found, performing a resync"
+        flags+=(--resync)
+    fi
+    rclone bisync "${RCLONE_FLAGS[@]}" "${flags[@]}" "${MOUNT_PATH}" "${REMOTE}" --log-level INFO 2>&1 |
+        tee /tmp/bisync.log
+    local conflicts
+    conflicts=$(grep -c "New or changed in both paths" /tmp/bisync.log || true)
+    echo "Bisync found ${conflicts} conflicts"
+    echo -n "conflicts: ${conflicts}" > /dev/termination-log
+}
+
+# Block volumes are attached as a device and are stored as a single file
+BLOCK_DEVICE="/dev/block"
+BLOCK_FILENAME="volume.img"
 
 START_TIME=$SECONDS
+if [[ -b "${BLOCK_DEVICE}" ]]; then
+    case "${DIRECTION}" in
+    source)
+        rclone rcat "${REMOTE}/${BLOCK_FILENAME}" < "${BLOCK_DEVICE}"
+        ;;
+    destination)
+        rclone cat "${REMOTE}/${BLOCK_FILENAME}" > "${BLOCK_DEVICE}"
+        ;;
+    *)
+        error 1 "unknown value for DIRECTION: ${DIRECTION}"
+        ;;
+    esac
+    sync
+    echo "Rclone completed in $(( SECONDS - START_TIME ))s"
+    exit 0
+fi
+if [[ "${DIRECTION}" == "source" && "${RCLONE_MODE}" == "Bisync" ]]; then
+    bisync
+    sync
+    echo "Rclone completed in $(( SECONDS - START_TIME ))s"
+    exit 0
+fi
 case "${DIRECTION}" in
 source)
-    getfacl -R "${MOUNT_PATH}" > "${MOUNT_PATH}"/permissons.facl
-    rclone sync "${RCLONE_FLAGS[@]}" "${MOUNT_PATH}" "${RCLONE_CONFIG_SECTION}:${RCLONE_DEST_PATH}" --log-level DEBUG
-    rm -rf "${MOUNT_PATH}"/permissons.facl
+    # Paths are recorded relative to MOUNT_PATH so they can be restored into a
+    # differently named mount
+    (cd "${MOUNT_PATH}" && getfacl -R -P .) > "${PERMISSIONS_FILE}"
+    case "${RCLONE_MODE:-Sync}" in
+    Sync)
+        rclone sync "${RCLONE_FLAGS[@]}" "${MOUNT_PATH}" "${REMOTE}" --log-level DEBUG
+        ;;
+    Copy)
+        rclone copy "${RCLONE_FLAGS[@]}" "${MOUNT_PATH}" "${REMOTE}" --log-level DEBUG
+        ;;
+    Backup)
+        rclone sync "${RCLONE_FLAGS[@]}" "${MOUNT_PATH}" "${REMOTE}" --log-level DEBUG \
+            --backup-dir "${REMOTE}/${VERSIONS_DIR}/$(date -u +%Y%m%d%H%M%S)"
+        prune_versions
+        ;;
+    *)
+        error 1 "unknown value for RCLONE_MODE: ${RCLONE_MODE}"
+        ;;
+    esac
+    rclone copyto "${PERMISSIONS_FILE}" "${PERMISSIONS_REMOTE}"
     rc=$?
     ;;
 destination)
-    rclone sync "${RCLONE_FLAGS[@]}" "${RCLONE_CONFIG_SECTION}:${RCLONE_DEST_PATH}" "${MOUNT_PATH}" --log-level DEBUG
-    setfacl --restore="${MOUNT_PATH}"/permissons.facl || true
-    rm -rf "${MOUNT_PATH}"/permissons.facl
+    rclone sync "${RCLONE_FLAGS[@]}" "${REMOTE}" "${MOUNT_PATH}" --log-level DEBUG
+    if ! rclone copyto "${PERMISSIONS_REMOTE}" "${PERMISSIONS_FILE}"; then
+        report_permissions_error "permissions metadata not found on the remote"
+    elif ! (cd "${MOUNT_PATH}" && setfacl --restore="${PERMISSIONS_FILE}") 2> /tmp/setfacl.err; then
+        cat /tmp/setfacl.err
+        report_permissions_error "$(head -n 5 /tmp/setfacl.err)"
+    fi
     rc=$?
     ;;
 *)
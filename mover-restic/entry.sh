@@ -10,6 +10,10 @@ echo  "$@"
 RESTIC_HOST="volsync"
 # Make restic output progress reports every 10s
 export RESTIC_PROGRESS_FPS=0.1
+# Block volumes are attached as a device instead of being mounted at DATA_DIR.
+# Their contents are stored as a single file in the snapshot.
+BLOCK_DEVICE="/dev/block"
+BLOCK_FILENAME="volume.img"
 
 # Print an error message and exit
 # error rc "message"
@@ -27,35 +31,69 @@ function check_var_defined {
 }
 
 function check_contents {
+    if [[ -b "${BLOCK_DEVICE}" ]]; then
+        return
+    fi
     echo "== Checking directory for content ==="
     DIR_CONTENTS="$(ls -A "${DATA_DIR}")"
     if [ -z "${DIR_CONTENTS}" ]; then
         echo "== Directory is empty skipping backup ==="
+        write_result
         exit 0
     fi
 }
 
-# Ensure the repo has been initialized
-function ensure_initialized {
+# Results reported back to the operator via the termination log
+REPOSITORY_STATE=""
+SNAPSHOT_LIST=""
+
+# Write the results of this run to the termination log so the operator can
+# publish them in the CR status
+function write_result {
+    jq -n -c --arg repo "${REPOSITORY_STATE}" --argjson snaps "${SNAPSHOT_LIST:-null}" \
+        '{repository: $repo, snapshots: $snaps} |
+         with_entries(select(.value != null and .value != ""))' \
+        > "${TERMINATION_LOG:-/dev/termination-log}"
+}
+
+# Check for the repository, creating it only if INITIALIZE_POLICY permits
+function do_init {
     echo "== Initialize Dir ======="
     # Try a restic command and capture the rc & output
     outfile=$(mktemp -q)
-    if ! restic snapshots 2>"$outfile"; then
+    if restic snapshots 2>"$outfile"; then
+        REPOSITORY_STATE="Found"
+    else
         output=$(<"$outfile")
         # Match against error string for uninitialized repo
-        if [[ $output =~ .*(Is there a repository at the following location).* ]]; then
-            restic init
-        else
+        if [[ ! $output =~ .*(Is there a repository at the following location).* ]]; then
             error 3 "failure checking existence of repository"
         fi
+        if [[ "${INITIALIZE_POLICY}" != "Auto" ]]; then
+            REPOSITORY_STATE="Missing"
+            write_result
+            error 4 "repository does not exist and initialize policy is ${INITIALIZE_POLICY}"
+        fi
+        restic init
+        REPOSITORY_STATE="Created"
     fi
     rm -f "$outfile"
 }
 
 function do_backup {
     echo "=== Starting backup ==="
+    # Snapshots are tagged with their source so they can be told apart from
+    # those of other sources that share the repository
+    local -a args=(--host "${RESTIC_HOST}")
+    if [[ -n "${SOURCE_TAG}" ]]; then
+        args+=(--tag "${SOURCE_TAG}")
+    fi
+    if [[ -b "${BLOCK_DEVICE}" ]]; then
+        restic backup "${args[@]}" --stdin --stdin-filename "${BLOCK_FILENAME}" < "${BLOCK_DEVICE}"
+        return
+    fi
     pushd "${DATA_DIR}"
-    restic backup --host "${RESTIC_HOST}" .
+    restic backup "${args[@]}" .
     popd
 }
 
@@ -72,10 +110,125 @@ function do_prune {
     restic prune
 }
 
+# Removes the snapshots made by this source (those tagged with SOURCE_TAG) as
+# well as the data that they reference. Snapshots of other sources, and those
+# made before snapshots were tagged, are left in place. A repository that
+# doesn't exist has nothing to remove.
+function do_delete {
+    echo "=== Deleting snapshots ==="
+    check_var_defined SOURCE_TAG
+    local outfile snapshots
+    outfile=$(mktemp -q)
+    if ! snapshots=$(restic snapshots --json --host "${RESTIC_HOST}" --tag "${SOURCE_TAG}" 2>"$outfile"); then
+        output=$(<"$outfile")
+        if [[ ! $output =~ .*(Is there a repository at the following location).* ]]; then
+            echo "$output"
+            error 3 "failure listing snapshots"
+        fi
+        echo "== Repository does not exist ==="
+        rm -f "$outfile"
+        return
+    fi
+    rm -f "$outfile"
+    local -a ids
+    mapfile -t ids < <(jq -r '.[].id' <<< "$snapshots")
+    if [[ ${#ids[@]} -gt 0 ]]; then
+        restic forget --prune "${ids[@]}"
+    fi
+}
+
+# Record the most recent snapshots of this source (newest first) so the
+# operator can publish them in the CR status
+function publish_snapshots {
+    echo "=== Listing snapshots ==="
+    local -a args=(--host "${RESTIC_HOST}")
+    if [[ -n "${SOURCE_TAG}" ]]; then
+        args+=(--tag "${SOURCE_TAG}")
+    fi
+    local snapshots
+    snapshots=$(restic snapshots --json "${args[@]}" | \
+        jq -c "sort_by(.time) | reverse | .[:${SNAPSHOT_LIST_MAX:-10}] |
+               map({id: .short_id, time: .time, paths: .paths, tags: (.tags // [])})")
+    # Determining the size walks the snapshot's tree, so it is only done once
+    # for each snapshot. The sizes are kept in the cache volume.
+    local sizes_file="${RESTIC_CACHE_DIR}/volsync-snapshot-sizes.json"
+    local known="{}"
+    if jq -e 'type == "object"' "${sizes_file}" > /dev/null 2>&1; then
+        known=$(<"${sizes_file}")
+    fi
+    local result="[]" sizes="{}"
+    while read -r snap; do
+        local id size
+        id=$(jq -r '.id' <<< "$snap")
+        size=$(jq -r --arg id "$id" '.[$id] // empty' <<< "$known")
+        if [[ -z "$size" ]]; then
+            size=$(restic stats --json --mode restore-size "$id" | jq '.total_size')
+        fi
+        sizes=$(jq -c --arg id "$id" --argjson sz "${size:-null}" '. + {($id): $sz}' <<< "$sizes")
+        result=$(jq -c --argjson s "$snap" --argjson sz "${size:-null}" \
+            '. + [$s + {size: $sz}]' <<< "$result")
+    done < <(jq -c '.[]' <<< "$snapshots")
+    echo "$sizes" > "${sizes_file}"
+    SNAPSHOT_LIST="$result"
+}
+
+# Removes any files from the restore target that are not part of the snapshot
+function delete_extraneous {
+    local snapshot="$1"
+    echo "=== Removing files not present in ${snapshot} ==="
+    restic ls --json --host "${RESTIC_HOST}" "${snapshot}" | \
+        jq -r 'select(.struct_type == "node") | .path' | sort > /tmp/snapshot-files
+    find . -mindepth 1 -printf '/%P\n' | sort > /tmp/local-files
+    comm -23 /tmp/local-files /tmp/snapshot-files | sort -r | while IFS= read -r f; do
+        rm -rf "./${f}"
+    done
+}
+
 function do_restore {
     echo "=== Starting restore ==="
+    local snapshot="${RESTORE_SNAPSHOT:-latest}"
+    if [[ -b "${BLOCK_DEVICE}" ]]; then
+        restic dump --host "${RESTIC_HOST}" "${snapshot}" "/${BLOCK_FILENAME}" > "${BLOCK_DEVICE}"
+        return
+    fi
+    local target="${DATA_DIR}"
+    if [[ -n "${RESTORE_SUBDIR}" ]]; then
+        target="${DATA_DIR}/${RESTORE_SUBDIR}"
+    fi
+    mkdir -p "${target}"
+    pushd "${target}"
+    if [[ "${RESTORE_CLEANUP}" == "Clean" ]]; then
+        echo "=== Removing existing contents of ${target} ==="
+        find . -mindepth 1 -delete
+    fi
+    local -a args=()
+    local pattern
+    while IFS= read -r pattern; do
+        [[ -n "${pattern}" ]] && args+=(--include "${pattern}")
+    done <<< "${RESTORE_INCLUDE}"
+    while IFS= read -r pattern; do
+        [[ -n "${pattern}" ]] && args+=(--exclude "${pattern}")
+    done <<< "${RESTORE_EXCLUDE}"
+    if [[ "${RESTORE_VERIFY}" == "true" ]]; then
+        args+=(--verify)
+    fi
+    restic restore -t . --host "${RESTIC_HOST}" "${args[@]}" "${snapshot}"
+    if [[ "${RESTORE_CLEANUP}" == "DeleteExtraneous" ]]; then
+        delete_extraneous "${snapshot}"
+    fi
+    popd
+}
+# Performs a test restore of the snapshot into the (scratch) data directory,
+# checking the restored files and the repository structure.
+function do_verify {
+    echo "=== Starting verification ==="
+    restic check
+    if [[ -b "${BLOCK_DEVICE}" ]]; then
+        restic dump --host "${RESTIC_HOST}" "${RESTORE_SNAPSHOT:-latest}" "/${BLOCK_FILENAME}" > "${BLOCK_DEVICE}"
+        return
+    fi
     pushd "${DATA_DIR}"
-    restic restore -t . --host "${RESTIC_HOST}" latest
+    restic restore -t . --host "${RESTIC_HOST}" --verify "${RESTORE_SNAPSHOT:-latest}"
     popd
 }
 echo "Testing mandatory env variables"
@@ -90,11 +243,14 @@ done
 
 for op in "$@"; do
     case $op in
+        "init")
+            do_init
+            ;;
         "backup")
             check_contents
-            ensure_initialized
             do_backup
             do_forget
+            publish_snapshots
             ;;
         "prune")
             do_prune
@@ -102,10 +258,17 @@ for op in "$@"; do
         "restore")
             do_restore
             ;;
+        "verify")
+            do_verify
+            ;;
+        "delete")
+            do_delete
+            ;;
         *)
             error 2 "unknown operation: $op"
             ;;
     esac
 done
+write_result
 sync
 echo "=== Done ==="
@@ -0,0 +1,115 @@
+#! /usr/bin/perl
+#
+# Changed-block synchronization of raw block devices
+#
+# blocksync.pl sums DEVICE
+#     Prints the checksum of each block of DEVICE, one per line
+# blocksync.pl diff DEVICE SUMS
+#     Compares the blocks of DEVICE against the checksums in the file SUMS and
+#     writes the blocks that differ to stdout as (offset, length, data) records
+# blocksync.pl apply DEVICE
+#     Writes the records read from stdin to DEVICE
+#
+# Statistics are printed to stderr.
+
+use strict;
+use warnings;
+use Digest::MD5 qw(md5_hex);
+use Fcntl qw(SEEK_SET);
+use IO::Handle;
+
+my $BLOCK_SIZE = 1024 * 1024;
+# Each record starts with a 64-bit offset and 32-bit length (big endian)
+my $HEADER_SIZE = 12;
+
+sub read_full {
+    my ($fh, $len) = @_;
+    my $buf = '';
+    while (length($buf) < $len) {
+        my $n = sysread($fh, $buf, $len - length($buf), length($buf));
+        die "read failed: $!\n" unless defined $n;
+        last if $n == 0;
+    }
+    return $buf;
+}
+
+sub write_full {
+    my ($fh, $buf) = @_;
+    my $off = 0;
+    while ($off < length($buf)) {
+        my $n = syswrite($fh, $buf, length($buf) - $off, $off);
+        die "write failed: $!\n" unless defined $n;
+        $off += $n;
+    }
+}
+
+sub open_device {
+    my ($path, $mode) = @_;
+    open(my $fh, $mode, $path) or die "unable to open $path: $!\n";
+    binmode($fh);
+    return $fh;
+}
+
+sub do_sums {
+    my ($device) = @_;
+    my $dev = open_device($device, '<');
+    my $blocks = 0;
+    while (length(my $block = read_full($dev, $BLOCK_SIZE))) {
+        print md5_hex($block), "\n";
+        $blocks++;
+    }
+    close($dev);
+    print STDERR "Checksummed $blocks blocks\n";
+}
+
+sub do_diff {
+    my ($device, $sums_file) = @_;
+    open(my $sums, '<', $sums_file) or die "unable to open $sums_file: $!\n";
+    my $dev = open_device($device, '<');
+    binmode(STDOUT);
+    my ($blocks, $changed, $offset) = (0, 0, 0);
+    while (length(my $block = read_full($dev, $BLOCK_SIZE))) {
+        my $remote = <$sums>;
+        chomp($remote) if defined $remote;
+        if (!defined $remote || $remote ne md5_hex($block)) {
+            write_full(\*STDOUT, pack('Q>N', $offset, length($block)) . $block);
+            $changed++;
+        }
+        $offset += length($block);
+        $blocks++;
+    }
+    close($dev);
+    close($sums);
+    print STDERR "Sent $changed of $blocks blocks\n";
+}
+
+sub do_apply {
+    my ($device) = @_;
+    my $dev = open_device($device, '+<');
+    binmode(STDIN);
+    my ($changed, $bytes) = (0, 0);
+    while (length(my $header = read_full(\*STDIN, $HEADER_SIZE))) {
+        die "truncated record header\n" if length($header) != $HEADER_SIZE;
+        my ($offset, $len) = unpack('Q>N', $header);
+        my $data = read_full(\*STDIN, $len);
+        die "truncated record data\n" if length($data) != $len;
+        sysseek($dev, $offset, SEEK_SET) or die "seek failed: $!\n";
+        write_full($dev, $data);
+        $changed++;
+        $bytes += $len;
+    }
+    $dev->sync() or die "sync failed: $!\n";
+    close($dev) or die "close failed: $!\n";
+    print STDERR "Wrote $changed blocks ($bytes bytes)\n";
+}
+
+my $op = shift @ARGV // '';
+if ($op eq 'sums' && @ARGV == 1) {
+    do_sums(@ARGV);
+} elsif ($op eq 'diff' && @ARGV == 2) {
+    do_diff(@ARGV);
+} elsif ($op eq 'apply' && @ARGV == 1) {
+    do_apply(@ARGV);
+} else {
+    die "usage: $0 sums DEVICE | diff DEVICE SUMS | apply DEVICE\n";
+}
@@ -16,6 +16,8 @@ function do_shutdown {
 }
 
 function do_rsync {
+    # Lets the main process know the source has connected
+    touch /tmp/connected
     # rsync changes are restricted to the /data directory of the container
     LANG=C rrsync /data
 }
@@ -24,6 +26,13 @@ function do_rsync {
 # Source can initiate an rsync
 if [[ "$SSH_ORIGINAL_COMMAND" =~ ^rsync( ) ]]; then
     do_rsync
+# Source can retrieve the checksums of, and write changed blocks to, a block
+# volume
+elif [[ "$SSH_ORIGINAL_COMMAND" == "blocksums" && -b /dev/block ]]; then
+    touch /tmp/connected
+    /blocksync.pl sums /dev/block
+elif [[ "$SSH_ORIGINAL_COMMAND" == "blockwrite" && -b /dev/block ]]; then
+    /blocksync.pl apply /dev/block
 # Source can tell us (destination) to shutdown & pass a numeric result code
 elif [[ "$SSH_ORIGINAL_COMMAND" =~ ^shutdown( )+([0-9]+)$ ]]; then
     do_shutdown "${BASH_REMATCH[2]}"
@@ -4,15 +4,54 @@ set -e -o pipefail
 
 echo "VolSync rsync container version: ${version:-unknown}"
 
-# Allow source's key to access, but restrict what it can do.
+# Allow source's key(s) to access, but restrict what it can do. During a key
+# rotation, there may be more than one.
 mkdir -p ~/.ssh
 chmod 700 ~/.ssh
-echo "command=\"/destination-command.sh\",restrict $(</keys/source.pub)" > ~/.ssh/authorized_keys
+while read -r key || [[ -n "$key" ]]; do
+    if [[ -n "$key" ]]; then
+        echo "command=\"/destination-command.sh\",restrict $key"
+    fi
+done < /keys/source.pub > ~/.ssh/authorized_keys
 
 # Wait for incoming rsync transfer
 echo "Waiting for connection..."
-rm -f /var/run/nologin
-/usr/sbin/sshd -D -e -q
+rm -f /var/run/nologin /tmp/connected
+/usr/sbin/sshd -D -e -q &
+SSHD_PID=$!
+
+# Timeouts of 0 mean there is no limit
+CONNECTION_TIMEOUT="${CONNECTION_TIMEOUT:-0}"
+TRANSFER_TIMEOUT="${TRANSFER_TIMEOUT:-0}"
+
+# Reports a timeout to the operator via the termination message, then stops
+# the listener
+function timed_out {
+    echo "Timed out: $1"
+    echo -n "timeout: $1" > /dev/termination-log
+    kill -SIGTERM "$SSHD_PID" || true
+    wait "$SSHD_PID" || true
+    exit 1
+}
+
+START_TIME=$SECONDS
+CONNECTED_TIME=""
+while kill -0 "$SSHD_PID" 2> /dev/null; do
+    if [[ -z "$CONNECTED_TIME" && -e /tmp/connected ]]; then
+        echo "Source connected"
+        CONNECTED_TIME=$SECONDS
+    fi
+    if [[ -z "$CONNECTED_TIME" && $CONNECTION_TIMEOUT -gt 0 &&
+          $(( SECONDS - START_TIME )) -ge $CONNECTION_TIMEOUT ]]; then
+        timed_out "no connection from the source within ${CONNECTION_TIMEOUT}s"
+    fi
+    if [[ -n "$CONNECTED_TIME" && $TRANSFER_TIMEOUT -gt 0 &&
+          $(( SECONDS - CONNECTED_TIME )) -ge $TRANSFER_TIMEOUT ]]; then
+        timed_out "transfer did not complete within ${TRANSFER_TIMEOUT}s"
+    fi
+    sleep 1
+done
+wait "$SSHD_PID" || true
 
 # When sshd exits, need to return the proper exit code from the rsync operation
 CODE=255
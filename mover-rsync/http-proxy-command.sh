@@ -0,0 +1,14 @@
+#! /bin/bash
+
+# ssh ProxyCommand that connects to host $1, port $2 through the HTTP proxy in
+# PROXY_HTTP. The proxy credentials are taken from the environment so that
+# they aren't written to the ssh configuration, where they would also be
+# subject to ssh's expansion of % tokens.
+
+set -e -o pipefail
+
+ARGS=(--proxy "${PROXY_HTTP}" --proxy-type http)
+if [[ -n "${PROXY_HTTP_USER}" ]]; then
+    ARGS+=(--proxy-auth "${PROXY_HTTP_USER}:${PROXY_HTTP_PASSWORD}")
+fi
+exec ncat "${ARGS[@]}" "$1" "$2"
@@ -11,11 +11,27 @@ if [[ -z "$DESTINATION_ADDRESS" ]]; then
     exit 1
 fi
 
+# IPv6 addresses must be enclosed in brackets when combined with a path or port
+RSYNC_HOST="${DESTINATION_ADDRESS}"
+if [[ "$DESTINATION_ADDRESS" == *:* ]]; then
+    RSYNC_HOST="[${DESTINATION_ADDRESS}]"
+fi
+# known_hosts entries include the port if it isn't the default
+KNOWN_HOST="${DESTINATION_ADDRESS}"
+if [[ "$DESTINATION_PORT" != "22" ]]; then
+    KNOWN_HOST="[${DESTINATION_ADDRESS}]:${DESTINATION_PORT}"
+fi
+
 mkdir -p ~/.ssh/controlmasters
 chmod 711 ~/.ssh
 
-# Provide ssh host key to validate remote
-echo "$DESTINATION_ADDRESS $(</keys/destination.pub)" > ~/.ssh/known_hosts
+# Provide ssh host key(s) to validate remote. During a key rotation, there may
+# be more than one.
+while read -r key || [[ -n "$key" ]]; do
+    if [[ -n "$key" ]]; then
+        echo "$KNOWN_HOST $key"
+    fi
+done < /keys/destination.pub > ~/.ssh/known_hosts
 
 cat - <<SSHCONFIG > ~/.ssh/config
 Host *
@@ -39,6 +55,115 @@ Host *
   TCPKeepAlive no
 SSHCONFIG
 
+# Optionally reach the destination through a jump host or HTTP proxy
+PROXY_COMMAND=""
+if [[ -n "$PROXY_JUMP_HOST" ]]; then
+    JUMP_KNOWN_HOST="${PROXY_JUMP_HOST}"
+    if [[ "$PROXY_JUMP_PORT" != "22" ]]; then
+        JUMP_KNOWN_HOST="[${PROXY_JUMP_HOST}]:${PROXY_JUMP_PORT}"
+    fi
+    # The jump host's key must be known, just like the destination's
+    if [[ ! -s /jump-keys/jumphost.pub ]]; then
+        echo "The jump host's public key must be provided in jumphost.pub"
+        exit 1
+    fi
+    while read -r key || [[ -n "$key" ]]; do
+        if [[ -n "$key" ]]; then
+            echo "$JUMP_KNOWN_HOST $key"
+        fi
+    done < /jump-keys/jumphost.pub > ~/.ssh/known_hosts_jump
+    PROXY_COMMAND="ssh -F /dev/null -i /jump-keys/jump -p ${PROXY_JUMP_PORT}"
+    PROXY_COMMAND+=" -o BatchMode=yes -o ConnectTimeout=30"
+    PROXY_COMMAND+=" -o UserKnownHostsFile=~/.ssh/known_hosts_jump -o StrictHostKeyChecking=yes"
+    PROXY_COMMAND+=" -W [%h]:%p ${PROXY_JUMP_USER}@${PROXY_JUMP_HOST}"
+    echo "Connecting via jump host ${PROXY_JUMP_USER}@${JUMP_KNOWN_HOST}"
+elif [[ -n "$PROXY_HTTP" ]]; then
+    # The credentials are read from the environment by the command
+    PROXY_COMMAND="/http-proxy-command.sh %h %p"
+    echo "Connecting via HTTP proxy ${PROXY_HTTP}"
+fi
+if [[ -n "$PROXY_COMMAND" ]]; then
+    echo "  ProxyCommand ${PROXY_COMMAND}" >> ~/.ssh/config
+fi
+
+RSYNC_OPTS=(--itemize-changes --info=stats2,misc2)
+PARALLELISM="${PARALLELISM:-1}"
+
+# Sums a statistic (e.g., "Number of files") across the logs of the streams
+function sum_stat {
+    local name="$1"
+    shift
+    sed -n "s/^${name}: \([0-9,]*\).*/\1/p" "$@" | tr -d ',' |
+        awk '{ sum += $1 } END { printf "%d", sum }'
+}
+
+# Transfers the volume using multiple rsync streams. The top-level entries
+# (and deletions) are handled first without recursion, then the top-level
+# directories are divided among PARALLELISM streams that share the ssh
+# connection. Returns the first non-zero exit code of the streams.
+function sync_parallel {
+    local i rc=0 stream_rc
+    local -a dirs pids
+
+    rsync -dlptgoDAhHSxz --delete "${RSYNC_OPTS[@]}" /data/ "root@${RSYNC_HOST}":. || return $?
+
+    mapfile -d '' dirs < <(find /data -mindepth 1 -maxdepth 1 -type d -printf '%P\0' | sort -z)
+    for ((i = 0; i < PARALLELISM; i++)); do
+        : > "/tmp/stream-${i}.list"
+    done
+    for i in "${!dirs[@]}"; do
+        printf '%s\0' "${dirs[$i]}" >> "/tmp/stream-$(( i % PARALLELISM )).list"
+    done
+
+    # Establish a shared connection so the streams don't each authenticate
+    ssh -fNM -o ControlPersist=yes "root@${DESTINATION_ADDRESS}" || return 255
+    for ((i = 0; i < PARALLELISM; i++)); do
+        if [[ -s "/tmp/stream-${i}.list" ]]; then
+            rsync -raAHSxz --delete --from0 --files-from="/tmp/stream-${i}.list" \
+                "${RSYNC_OPTS[@]}" /data/ "root@${RSYNC_HOST}":. > "/tmp/stream-${i}.log" 2>&1 &
+            pids[i]=$!
+        fi
+    done
+    for i in "${!pids[@]}"; do
+        wait "${pids[$i]}"
+        stream_rc=$?
+        sed "s/^/[stream ${i}] /" "/tmp/stream-${i}.log"
+        if [[ $stream_rc -ne 0 && $rc -eq 0 ]]; then
+            rc=$stream_rc
+        fi
+    done
+    ssh -O exit "root@${DESTINATION_ADDRESS}" 2> /dev/null || true
+
+    # The streams report plain numbers (no -h) so their statistics can be summed
+    if [[ ${#pids[@]} -gt 0 ]]; then
+        local logs=()
+        for i in "${!pids[@]}"; do
+            logs+=("/tmp/stream-${i}.log")
+        done
+        echo "Combined statistics for ${#pids[@]} streams:"
+        echo "Number of files: $(sum_stat "Number of files" "${logs[@]}")"
+        echo "Number of regular files transferred: $(sum_stat "Number of regular files transferred" "${logs[@]}")"
+        echo "Total file size: $(sum_stat "Total file size" "${logs[@]}") bytes"
+        echo "Total transferred file size: $(sum_stat "Total transferred file size" "${logs[@]}") bytes"
+    fi
+    return $rc
+}
+
+# Transfers a block volume by sending only the blocks that differ from those
+# already on the destination device
+function sync_block {
+    echo "Retrieving block checksums from the destination..."
+    ssh "root@${DESTINATION_ADDRESS}" blocksums > /tmp/blocksums || return $?
+    /blocksync.pl diff /dev/block /tmp/blocksums | ssh "root@${DESTINATION_ADDRESS}" blockwrite
+}
+
+# Independent streams can't preserve hard links between files that are sent by
+# different streams, so volumes with hard links are sent using a single stream
+if [[ $PARALLELISM -gt 1 && -n "$(find /data -xdev -type f -links +1 -print -quit 2>/dev/null)" ]]; then
+    echo "The volume contains hard links. Using a single stream to preserve them."
+    PARALLELISM=1
+fi
+
 MAX_RETRIES=5
 RETRY=0
 DELAY=2
@@ -51,7 +176,13 @@ set +e
 while [[ ${rc} -ne 0 && ${RETRY} -lt ${MAX_RETRIES} ]]
 do
     RETRY=$((RETRY + 1))
-    rsync -aAhHSxz --delete --itemize-changes --info=stats2,misc2 /data/ "root@${DESTINATION_ADDRESS}":.
+    if [[ -b /dev/block ]]; then
+        sync_block
+    elif [[ $PARALLELISM -gt 1 ]]; then
+        sync_parallel
+    else
+        rsync -aAhHSxz --delete "${RSYNC_OPTS[@]}" /data/ "root@${RSYNC_HOST}":.
+    fi
     rc=$?
     if [[ ${rc} -ne 0 ]]; then
         echo "Syncronization failed. Retrying in ${DELAY} seconds. Retry ${RETRY}/${MAX_RETRIES}."
@@ -0,0 +1,59 @@
+#! /bin/bash
+
+set -e -o pipefail
+
+echo "VolSync rsync-tls container version: ${version:-unknown}"
+
+# The control directory is where the source signals that it's done
+CONTROL_DIR=/tmp/control
+RUN_DIR=/tmp/rsync-tls
+mkdir -p "$CONTROL_DIR" "$RUN_DIR"
+
+# rsync daemon only listens on localhost; all external connections come in via
+# stunnel. No chroot is used, so root and SYS_CHROOT aren't needed. Symlinks
+# are munged (the default w/o chroot) so they can't be used to write outside of
+# the module. The daemon runs as the container's (unprivileged) user, so files
+# are owned by it.
+cat - <<RSYNCDCONF > "$RUN_DIR/rsyncd.conf"
+pid file = $RUN_DIR/rsyncd.pid
+use chroot = no
+uid = $(id -u)
+gid = $(id -g)
+numeric ids = yes
+read only = no
+log file = /dev/stdout
+
+[data]
+    path = /data
+
+[control]
+    path = $CONTROL_DIR
+RSYNCDCONF
+
+cat - <<STUNNELCONF > "$RUN_DIR/stunnel.conf"
+foreground = no
+pid = $RUN_DIR/stunnel.pid
+output = /dev/stdout
+debug = notice
+
+[rsync]
+ciphers = PSK
+PSKsecrets = /keys/psk.txt
+accept = 8000
+connect = 127.0.0.1:8873
+STUNNELCONF
+
+rsync --daemon --address=127.0.0.1 --port=8873 --config="$RUN_DIR/rsyncd.conf"
+stunnel "$RUN_DIR/stunnel.conf"
+
+echo "Waiting for connection..."
+while [[ ! -e "$CONTROL_DIR/complete" ]]; do
+    sleep 1
+done
+
+rc="$(cat "$CONTROL_DIR/complete")"
+echo "Source reported completion with: ${rc}"
+
+kill "$(cat "$RUN_DIR/stunnel.pid")" "$(cat "$RUN_DIR/rsyncd.pid")" || true
+sync
+exit "${rc:-1}"
@@ -0,0 +1,68 @@
+#! /bin/bash
+
+set -e -o pipefail
+
+echo "VolSync rsync-tls container version: ${version:-unknown}"
+
+# Ensure we have connection info for the destination
+DESTINATION_PORT="${DESTINATION_PORT:-8000}"
+if [[ -z "$DESTINATION_ADDRESS" ]]; then
+    echo "Remote host must be provided in DESTINATION_ADDRESS"
+    exit 1
+fi
+
+RUN_DIR=/tmp/rsync-tls
+mkdir -p "$RUN_DIR"
+
+# stunnel wraps the connection to the destination's rsync daemon in TLS,
+# authenticated by the pre-shared key
+cat - <<STUNNELCONF > "$RUN_DIR/stunnel.conf"
+foreground = no
+pid = $RUN_DIR/stunnel.pid
+output = /dev/stdout
+debug = notice
+
+[rsync]
+client = yes
+ciphers = PSK
+PSKsecrets = /keys/psk.txt
+accept = 127.0.0.1:8873
+connect = ${DESTINATION_ADDRESS}:${DESTINATION_PORT}
+STUNNELCONF
+
+stunnel "$RUN_DIR/stunnel.conf"
+
+MAX_RETRIES=5
+RETRY=0
+DELAY=2
+FACTOR=2
+rc=1
+echo "Syncing data to ${DESTINATION_ADDRESS}:${DESTINATION_PORT} ..."
+START_TIME=$SECONDS
+# Avoids exiting on rsync failure
+set +e
+while [[ ${rc} -ne 0 && ${RETRY} -lt ${MAX_RETRIES} ]]
+do
+    RETRY=$((RETRY + 1))
+    # Ownership can't be set by the unprivileged destination, so -o/-g are
+    # omitted from the archive options
+    rsync -rlptDAhHSxz --delete --itemize-changes --info=stats2,misc2 /data/ rsync://127.0.0.1:8873/data/
+    rc=$?
+    if [[ ${rc} -ne 0 ]]; then
+        echo "Syncronization failed. Retrying in ${DELAY} seconds. Retry ${RETRY}/${MAX_RETRIES}."
+        sleep ${DELAY}
+        DELAY=$((DELAY * FACTOR ))
+    fi
+done
+set -e
+echo "Rsync completed in $(( SECONDS - START_TIME ))s"
+sync
+if [[ $rc -eq 0 ]]; then
+    echo "Synchronization completed successfully. Notifying destination..."
+else
+    echo "Synchronization failed. rsync returned: $rc"
+fi
+echo "$rc" > "$RUN_DIR/complete"
+rsync "$RUN_DIR/complete" rsync://127.0.0.1:8873/control/
+kill "$(cat "$RUN_DIR/stunnel.pid")" || true
+exit $rc
@@ -12,6 +12,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 )
 
 type SSHKeysSecretOptions struct {
@@ -50,6 +52,8 @@ func (o *SSHKeysSecretOptions) SyncSSHSecret() error {
 		Name:            originalSecret.ObjectMeta.Name,
 		Namespace:       o.RepOpts.Source.Namespace,
 		OwnerReferences: nil,
+		// Allows the copy to be removed with the ReplicationSource
+		Labels: map[string]string{volsyncv1alpha1.CopiedSSHKeysLabel: "true"},
 	}
 
 	err = o.RepOpts.Source.Client.Create(ctx, newSecret)